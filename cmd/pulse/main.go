@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
 	"github.com/guidefari/pulse/internal/cli"
 	"github.com/guidefari/pulse/internal/core"
@@ -12,21 +14,73 @@ import (
 )
 
 func main() {
+	if cli.RunSubcommand(os.Args[1:]) {
+		return
+	}
+
 	cliConfig := cli.ParseFlags()
 
 	exporter, shutdown := tracing.Init(cliConfig.ShowTimings)
 	ctx := context.Background()
 
+	// The first Ctrl-C cancels the scan so whatever finished still gets
+	// rendered; a second one kills the process as usual.
+	scanCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-scanCtx.Done()
+		stop()
+	}()
+
 	config := core.ScanConfig{
-		RootPath:       cliConfig.RootPath,
-		MaxDepth:       cliConfig.MaxDepth,
-		DetailMode:     cliConfig.DetailMode,
-		Fetch:          cliConfig.Fetch,
-		GhostThreshold: core.DefaultGhostThreshold,
-		WorkerCount:    4,
+		Roots:               cliConfig.Roots,
+		MaxDepth:            cliConfig.MaxDepth,
+		DetailMode:          cliConfig.DetailMode,
+		Fetch:               cliConfig.Fetch,
+		FetchTimeout:        cliConfig.FetchTimeout,
+		FetchWorkers:        cliConfig.FetchWorkers,
+		GhostThreshold:      core.DefaultGhostThreshold,
+		WorkerCount:         4,
+		IgnoreFile:          cliConfig.IgnoreFile,
+		ReportIgnored:       cliConfig.ShowIgnored,
+		CacheFile:           cliConfig.CacheFile,
+		Rescan:              cliConfig.Rescan,
+		RegistryFile:        cliConfig.RegistryFile,
+		RegistryOnly:        cliConfig.RegistryOnly,
+		FollowSymlinks:      cliConfig.FollowSymlinks,
+		OneFileSystem:       cliConfig.OneFileSystem,
+		UntrackedProjects:   cliConfig.UntrackedProjects,
+		Branches:            cliConfig.Branches,
+		IgnoredFiles:        cliConfig.IgnoredFiles,
+		Releases:            cliConfig.Releases,
+		UnreleasedOlderThan: time.Duration(cliConfig.UnreleasedDays) * 24 * time.Hour,
+		ActivityDays:        cliConfig.ActivityDays,
+		Heatmap:             cliConfig.Heatmap,
+		Location:            cliConfig.Location,
+		RepoTimeout:         cliConfig.RepoTimeout,
+		ScanTimeout:         cliConfig.ScanTimeout,
+		DayStartHour:        cliConfig.DayStartHour,
+		LinesExclude:        cliConfig.LinesExclude,
+		IncludeMerges:       cliConfig.IncludeMerges,
+		EnableProbes:        cliConfig.EnableProbes,
+		DisableProbes:       cliConfig.DisableProbes,
+		Authors: core.AuthorFilter{
+			Mine:    cliConfig.Mine,
+			Aliases: cliConfig.AuthorAliases,
+		},
 	}
 
-	result, err := pulse.Run(ctx, config)
+	if cliConfig.ListProbes {
+		probes, err := pulse.Probes(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		cli.RenderProbeList(probes)
+		return
+	}
+
+	result, err := pulse.Run(scanCtx, config)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -40,6 +94,14 @@ func main() {
 		cli.RenderDetail(result)
 	}
 
+	// Each of these prints nothing unless its probe ran, whether a flag like
+	// --heatmap, --probe or a custom probe's dependencies turned it on.
+	if cliConfig.Format == "table" {
+		cli.RenderHeatmaps(result)
+		cli.RenderBranches(result)
+		cli.RenderProbes(result)
+	}
+
 	if cliConfig.ShowTimings {
 		cli.RenderTimings(exporter)
 	}
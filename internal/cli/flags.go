@@ -4,32 +4,178 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guidefari/pulse/internal/core"
 )
 
 type CLIConfig struct {
-	RootPath    string
-	MaxDepth    int
-	DetailMode  bool
-	Fetch       bool
-	Format      string
-	ShowTimings bool
+	Roots             []core.ScanRoot
+	MaxDepth          int
+	DetailMode        bool
+	Fetch             bool
+	Format            string
+	ShowTimings       bool
+	IgnoreFile        string
+	ShowIgnored       bool
+	CacheFile         string
+	Rescan            bool
+	RegistryFile      string
+	RegistryOnly      bool
+	FollowSymlinks    bool
+	OneFileSystem     bool
+	UntrackedProjects bool
+	Branches          bool
+	IgnoredFiles      bool
+	Releases          bool
+	UnreleasedDays    int
+	Mine              bool
+	AuthorAliases     []string
+	ActivityDays      int
+	Heatmap           bool
+	Location          *time.Location
+	DayStartHour      int
+	LinesExclude      []string
+	IncludeMerges     bool
+	FetchTimeout      time.Duration
+	FetchWorkers      int
+	RepoTimeout       time.Duration
+	ScanTimeout       time.Duration
+	EnableProbes      []string
+	DisableProbes     []string
+	ListProbes        bool
 }
 
 func ParseFlags() CLIConfig {
 	var config CLIConfig
 
-	flag.StringVar(&config.RootPath, "path", ".", "root directory to scan for git repos")
+	var roots rootsFlag
+	flag.Var(&roots, "path", "root directory to scan for git repos, optionally suffixed with :depth (repeatable)")
 	flag.IntVar(&config.MaxDepth, "depth", 3, "maximum directory depth to scan")
 	flag.BoolVar(&config.DetailMode, "detail", false, "show detailed commit history")
 	flag.BoolVar(&config.Fetch, "fetch", false, "fetch from remotes before checking ahead/behind status")
+	flag.DurationVar(&config.FetchTimeout, "fetch-timeout", core.DefaultFetchTimeout, "give up on a repo's fetch after this long")
+	flag.IntVar(&config.FetchWorkers, "fetch-jobs", core.DefaultFetchWorkers, "number of fetches to run at once")
 	flag.StringVar(&config.Format, "format", "table", "output format: table or json")
 	flag.BoolVar(&config.ShowTimings, "time", false, "show performance timing breakdown")
+	flag.DurationVar(&config.RepoTimeout, "repo-timeout", core.DefaultRepoTimeout, "give up on analyzing a single repo after this long (0 for no limit)")
+	flag.DurationVar(&config.ScanTimeout, "timeout", 0, "stop the whole scan after this long and report what finished (0 for no limit)")
+	flag.StringVar(&config.IgnoreFile, "ignore-file", core.DefaultIgnoreFile(), "global ignore file with gitignore-style patterns")
+	flag.BoolVar(&config.ShowIgnored, "show-ignored", false, "report directories excluded by ignore rules and the rule that matched")
+	flag.StringVar(&config.CacheFile, "cache-file", core.DefaultCacheFile(), "discovery cache location")
+	noCache := flag.Bool("no-cache", false, "walk the whole tree without reading or writing the discovery cache")
+	flag.BoolVar(&config.Rescan, "rescan", false, "ignore cached directory listings and re-walk everything, then refresh the cache")
+	flag.StringVar(&config.RegistryFile, "registry-file", core.DefaultRegistryFile(), "registry of explicitly tracked repos (see pulse add)")
+	flag.BoolVar(&config.RegistryOnly, "registry-only", false, "scan only registered repos, skipping the directory walk")
+	flag.BoolVar(&config.FollowSymlinks, "follow-symlinks", false, "descend into symlinked directories, skipping cycles")
+	flag.BoolVar(&config.OneFileSystem, "one-file-system", false, "do not cross into other mounted filesystems")
+	flag.BoolVar(&config.UntrackedProjects, "untracked-projects", false, "list only non-git directories that look like unversioned projects")
+	flag.BoolVar(&config.Branches, "branches", false, "analyze every local branch, not just the checked-out one")
+	flag.BoolVar(&config.IgnoredFiles, "ignored-files", false, "count files ignored by .gitignore in the worktree status")
+	flag.BoolVar(&config.Releases, "releases", false, "find each repo's latest semver tag and the commits made since")
+	flag.IntVar(&config.UnreleasedDays, "unreleased-older-than", 0, "only show repos whose oldest unreleased commit is older than this many days")
+	flag.BoolVar(&config.Mine, "mine", false, "count only your own commits, as identified by each repo's user.name and user.email")
+	flag.IntVar(&config.ActivityDays, "activity-days", core.DefaultActivityDays, "days covered by the activity sparkline and lines changed")
+	flag.BoolVar(&config.Heatmap, "heatmap", false, "show a 52-week contribution heatmap for the whole tree and each repo")
+	timezone := flag.String("timezone", "", "IANA zone to bucket commits into days, e.g. Europe/Berlin (default: local)")
+	flag.IntVar(&config.DayStartHour, "day-start", 0, "hour (0-23) at which a new day begins, for counting late-night commits")
+	flag.BoolVar(&config.IncludeMerges, "include-merges", false, "count merge commits (their first-parent diff) in lines changed")
+	var excludes stringsFlag
+	flag.Var(&excludes, "lines-exclude", "gitignore-style pattern for files left out of lines changed (repeatable)")
+	var enable, disable listFlag
+	flag.Var(&enable, "probe", "run this probe, and the probes it depends on, even if off by default (repeatable, or comma-separated)")
+	flag.Var(&disable, "skip-probe", "skip this probe and every probe that depends on it (repeatable, or comma-separated)")
+	flag.BoolVar(&config.ListProbes, "list-probes", false, "list the available probes, their cost and whether they would run, then exit")
+	var aliases stringsFlag
+	flag.Var(&aliases, "author", "also count commits by this name or email as yours (repeatable)")
 	flag.Parse()
 
+	config.AuthorAliases = aliases
+	config.LinesExclude = excludes
+	config.EnableProbes = enable
+	config.DisableProbes = disable
+
+	if len(roots) == 0 {
+		roots = rootsFlag{{Path: "."}}
+	}
+	config.Roots = roots
+
+	if *noCache {
+		config.CacheFile = ""
+	}
+
 	if config.Format != "table" && config.Format != "json" {
 		fmt.Fprintf(os.Stderr, "invalid format %q, must be 'table' or 'json'\n", config.Format)
 		os.Exit(1)
 	}
 
+	config.Location = time.Local
+	if *timezone != "" {
+		loc, err := time.LoadLocation(*timezone)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid timezone %q: %v\n", *timezone, err)
+			os.Exit(1)
+		}
+		config.Location = loc
+	}
+
+	if config.DayStartHour < 0 || config.DayStartHour > 23 {
+		fmt.Fprintf(os.Stderr, "invalid day start %d, must be an hour from 0 to 23\n", config.DayStartHour)
+		os.Exit(1)
+	}
+
 	return config
 }
+
+type rootsFlag []core.ScanRoot
+
+func (r *rootsFlag) String() string {
+	paths := make([]string, len(*r))
+	for i, root := range *r {
+		paths[i] = root.Path
+	}
+	return strings.Join(paths, ",")
+}
+
+func (r *rootsFlag) Set(value string) error {
+	root := core.ScanRoot{Path: value}
+	if i := strings.LastIndex(value, ":"); i > 0 {
+		if depth, err := strconv.Atoi(value[i+1:]); err == nil {
+			if depth <= 0 {
+				return fmt.Errorf("invalid depth %d for %s", depth, value[:i])
+			}
+			root = core.ScanRoot{Path: value[:i], MaxDepth: depth}
+		}
+	}
+	*r = append(*r, root)
+	return nil
+}
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// listFlag is a stringsFlag that also splits each value on commas.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guidefari/pulse/internal/core"
+)
+
+var heatShades = []string{"·", "░", "▒", "▓", "█"}
+
+func RenderHeatmaps(result *core.ScanResult) {
+	if result.Heatmap == nil {
+		return
+	}
+
+	fmt.Printf("\n%s  %s\n", cyan("▦"), heatmapTitle("all repos", result.Heatmap))
+	renderHeatmap(result.Heatmap)
+
+	for i := len(result.Repos) - 1; i >= 0; i-- {
+		repo := result.Repos[i]
+		if repo.Heatmap == nil || repo.Heatmap.Total == 0 {
+			continue
+		}
+		fmt.Printf("\n%s %s\n", cyan("─────"), heatmapTitle(repo.Name, repo.Heatmap))
+		renderHeatmap(repo.Heatmap)
+	}
+	fmt.Println()
+}
+
+func heatmapTitle(name string, h *core.Heatmap) string {
+	return fmt.Sprintf("%s  %s", name, dim(fmt.Sprintf("%d commits in the last year", h.Total)))
+}
+
+// renderHeatmap draws one column per week and one row per weekday, Sunday at
+// the top, with month names over the week in which each month begins.
+func renderHeatmap(h *core.Heatmap) {
+	start, err := time.Parse("2006-01-02", h.Start)
+	if err != nil {
+		return
+	}
+	weeks := (len(h.Days) + 6) / 7
+
+	months := []rune(strings.Repeat(" ", weeks+3))
+	for w := 0; w < weeks; w++ {
+		first := start.AddDate(0, 0, 7*w)
+		for d := 0; d < 7; d++ {
+			day := first.AddDate(0, 0, d)
+			if day.Day() == 1 && w+3 <= len(months) {
+				copy(months[w:], []rune(day.Format("Jan")))
+				break
+			}
+		}
+	}
+	fmt.Printf("      %s\n", dim(strings.TrimRight(string(months), " ")))
+
+	labels := []string{"", "Mon", "", "Wed", "", "Fri", ""}
+	for d := 0; d < 7; d++ {
+		var b strings.Builder
+		for w := 0; w < weeks; w++ {
+			i := 7*w + d
+			if i >= len(h.Days) {
+				break
+			}
+			b.WriteString(heatCell(h.Days[i], h.Max))
+		}
+		fmt.Printf("  %s %s\n", dim(fmt.Sprintf("%-3s", labels[d])), b.String())
+	}
+
+	legend := make([]string, len(heatShades))
+	for i, s := range heatShades {
+		legend[i] = heatColor(i, s)
+	}
+	fmt.Printf("      %s %s %s\n", dim("less"), strings.Join(legend, ""), dim("more"))
+}
+
+// heatCell shades by quarters of the busiest day, as GitHub does.
+func heatCell(n, max int) string {
+	if n == 0 || max == 0 {
+		return heatColor(0, heatShades[0])
+	}
+	level := (n*4 + max - 1) / max
+	return heatColor(level, heatShades[level])
+}
+
+func heatColor(level int, s string) string {
+	if level == 0 {
+		return dim(s)
+	}
+	return green(s)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/guidefari/pulse/internal/core"
+)
+
+var subcommands = map[string]func(*core.Registry, []string) error{
+	"add":    addRepos,
+	"remove": removeRepos,
+	"list":   listRepos,
+}
+
+func RunSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	run, ok := subcommands[args[0]]
+	if !ok {
+		return false
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	file := fs.String("registry-file", core.DefaultRegistryFile(), "registry of explicitly tracked repos")
+	fs.Parse(args[1:])
+
+	registry, err := core.LoadRegistry(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(registry, fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+func addRepos(registry *core.Registry, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	failed := 0
+	for _, p := range paths {
+		path, err := registry.Add(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s  %v\n", red("✘"), err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s  %s\n", green("✔"), path)
+	}
+
+	if err := registry.Save(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d paths not added", failed)
+	}
+	return nil
+}
+
+func removeRepos(registry *core.Registry, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	failed := 0
+	for _, p := range paths {
+		path, ok := registry.Remove(p)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s  %s is not registered\n", red("✘"), path)
+			failed++
+			continue
+		}
+		fmt.Printf("%s  %s\n", dim("removed"), path)
+	}
+
+	if err := registry.Save(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d paths not removed", failed)
+	}
+	return nil
+}
+
+func listRepos(registry *core.Registry, _ []string) error {
+	repos := registry.Repos()
+	if len(repos) == 0 {
+		fmt.Println(dim("no registered repos in " + registry.Path()))
+		return nil
+	}
+
+	for _, repo := range repos {
+		if core.IsRepository(repo) {
+			fmt.Printf("%s  %s\n", green("✔"), repo)
+		} else {
+			fmt.Printf("%s  %s %s\n", red("✘"), repo, dim("(missing)"))
+		}
+	}
+	return nil
+}
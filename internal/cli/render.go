@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -19,10 +20,11 @@ import (
 )
 
 var (
-	green = color.New(color.FgGreen).SprintFunc()
-	red   = color.New(color.FgRed).SprintFunc()
-	dim   = color.New(color.Faint).SprintFunc()
-	cyan  = color.New(color.FgCyan).SprintFunc()
+	green  = color.New(color.FgGreen).SprintFunc()
+	red    = color.New(color.FgRed).SprintFunc()
+	dim    = color.New(color.Faint).SprintFunc()
+	cyan   = color.New(color.FgCyan).SprintFunc()
+	yellow = color.New(color.FgYellow).SprintFunc()
 )
 
 func Render(result *core.ScanResult, format string) {
@@ -34,79 +36,582 @@ func Render(result *core.ScanResult, format string) {
 }
 
 func RenderTable(result *core.ScanResult) {
+	if len(result.Roots) > 1 {
+		fmt.Printf("\n%s  Found %d repos in %d roots (scanned in %s)%s\n",
+			cyan("pulse"),
+			result.TotalRepos,
+			len(result.Roots),
+			result.ScanDuration.Round(time.Millisecond),
+			authorNote(result.Repos))
+
+		for _, root := range result.Roots {
+			label := cyan(root.Path)
+			if root.Registry {
+				label = cyan("registered") + dim(" ("+root.Path+")")
+			}
+			fmt.Printf("\n%s  %s\n\n", label, dim(rootTotals(root)))
+			var repos []core.RepoStatus
+			for _, repo := range result.Repos {
+				if repo.Root == root.Path {
+					repos = append(repos, repo)
+				}
+			}
+			renderRepoTable(repos)
+		}
+	} else {
+		fmt.Printf("\n%s  Found %d repos (scanned in %s)%s\n\n",
+			cyan("pulse"),
+			result.TotalRepos,
+			result.ScanDuration.Round(time.Millisecond),
+			authorNote(result.Repos))
+		renderRepoTable(result.Repos)
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Printf("\n%s  %s\n", red("!"), plural(len(result.Errors), "error"))
+		for _, e := range result.Errors {
+			fmt.Printf("  %s: %s\n", dim(e.Path), e.Message)
+		}
+	}
+
+	if result.Stopped != "" || len(result.Unfinished) > 0 {
+		renderUnfinished(result)
+	}
+
+	var unfetched []core.RepoStatus
+	for _, repo := range result.Repos {
+		if fetchNote(repo.Fetch) != "" {
+			unfetched = append(unfetched, repo)
+		}
+	}
+	if len(unfetched) > 0 {
+		fmt.Printf("\n%s  %d repos could not fetch, ahead/behind may be stale\n", red("⚠"), len(unfetched))
+		for _, repo := range unfetched {
+			fmt.Printf("  %-20s %s %s\n", repo.Name, fetchLabel(repo.Fetch.Outcome), dim(repo.Fetch.Message))
+		}
+	}
+
+	var stopped []core.RepoStatus
+	for _, repo := range result.Repos {
+		if len(repo.Operations) > 0 {
+			stopped = append(stopped, repo)
+		}
+	}
+	if len(stopped) > 0 {
+		fmt.Printf("\n%s  %d repos stopped mid-operation\n", red("⏸"), len(stopped))
+		for _, repo := range stopped {
+			for _, op := range repo.Operations {
+				fmt.Printf("  %-20s %s\n", repo.Name, operationLong(op))
+			}
+		}
+	}
+
+	if len(result.NonGitDirs) > 0 {
+		projects := 0
+		for _, d := range result.NonGitDirs {
+			if d.LikelyProject() {
+				projects++
+			}
+		}
+		fmt.Printf("\n%s  %d non-git directories (%d look like projects)\n",
+			dim("📁"), len(result.NonGitDirs), projects)
+		for _, d := range result.NonGitDirs {
+			fmt.Printf("  %-24s %s\n", d.Path, nonGitDetails(d))
+		}
+	}
+
+	if len(result.Duplicates) > 0 {
+		fmt.Printf("\n%s  %d duplicate clone groups\n", cyan("⧉"), len(result.Duplicates))
+		for _, group := range result.Duplicates {
+			fmt.Printf("  %s\n", group.Key)
+			for _, clone := range group.Clones {
+				var notes []string
+				if clone.Newest {
+					notes = append(notes, green("newest"))
+				}
+				switch {
+				case clone.NoRemote:
+					notes = append(notes, yellow("no remote, all commits local"))
+				case clone.LocalOnlyCommits > 0:
+					notes = append(notes, red(fmt.Sprintf("%d local-only commits", clone.LocalOnlyCommits)))
+				}
+				if clone.Kind != core.KindWorking {
+					notes = append(notes, dim(string(clone.Kind)))
+				}
+				notes = append(notes, dim(timeAgo(clone.LastCommitTime)))
+				fmt.Printf("    %-20s %s %s\n", clone.Name, strings.Join(notes, dim(" · ")), dim(clone.Path))
+			}
+		}
+	}
+
+	if len(result.Ignored) > 0 {
+		fmt.Printf("\n%s  %d ignored directories\n", dim("🚫"), len(result.Ignored))
+		for _, ig := range result.Ignored {
+			fmt.Printf("  %s %s\n", ig.Path, dim(fmt.Sprintf("(%s from %s)", ig.Rule, ig.Source)))
+		}
+	}
+
+	if result.Activity != nil {
+		renderActivity(result)
+	}
+
+	fmt.Println()
+}
+
+const activityTopRepos = 5
+
+// renderActivity summarises the tree-wide tally: today, the whole window with
+// its busiest repos, and how commits fall across the week.
+func renderActivity(result *core.ScanResult) {
+	activity := result.Activity
+	today := activity.Days[len(activity.Days)-1]
+	fmt.Printf("\n%s  %d commits today across %s %s\n",
+		cyan("📊"), activity.Today, plural(len(today.Repos), "repo"), dim(dayNote(result)))
+	if activity.Total == 0 {
+		return
+	}
+
+	var top []string
+	for i, repo := range activity.Repos {
+		if i == activityTopRepos {
+			top = append(top, fmt.Sprintf("+%d more", len(activity.Repos)-i))
+			break
+		}
+		top = append(top, fmt.Sprintf("%s %d", repo.Name, repo.Commits))
+	}
+	fmt.Printf("   %d commits in the last %d days %s\n",
+		activity.Total, len(activity.Days), dim("("+strings.Join(top, ", ")+")"))
+
+	weekdays := make([]string, len(activity.Weekdays))
+	for i, n := range activity.Weekdays {
+		weekdays[i] = fmt.Sprintf("%s %d", time.Weekday(i).String()[:3], n)
+	}
+	fmt.Printf("   %s\n", dim(strings.Join(weekdays, "  ")))
+}
+
+const maxLanguages = 4
+
+// languageBreakdown lists the languages with the most churn and the lines
+// left out as generated, vendored or excluded.
+func languageBreakdown(lines *core.LinesChanged) string {
+	var parts []string
+	for i, l := range lines.ByLanguage {
+		if i == maxLanguages {
+			parts = append(parts, fmt.Sprintf("+%d more", len(lines.ByLanguage)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s +%d -%d", l.Language, l.Added, l.Removed))
+	}
+	if lines.ExcludedAdded > 0 || lines.ExcludedRemoved > 0 {
+		parts = append(parts, fmt.Sprintf("excluded +%d -%d", lines.ExcludedAdded, lines.ExcludedRemoved))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + dim(strings.Join(parts, " · "))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// dayNote names the zone and day boundary "today" was judged by.
+func dayNote(result *core.ScanResult) string {
+	if result.DayStartHour != 0 {
+		return fmt.Sprintf("(%s, day starts %02d:00)", result.Timezone, result.DayStartHour)
+	}
+	return fmt.Sprintf("(%s)", result.Timezone)
+}
+
+func authorNote(repos []core.RepoStatus) string {
+	for _, repo := range repos {
+		if len(repo.Authors) > 0 {
+			return dim(" · activity counts your commits only")
+		}
+	}
+	return ""
+}
+
+func renderRepoTable(repos []core.RepoStatus) {
+	header := []string{"Repo", "Status", "Last Active", "Branch", "Ahead/Behind", "Activity"}
+	for _, repo := range repos {
+		if n := len(repo.DailyActivity); n > 0 && n != core.DefaultActivityDays {
+			header[5] = fmt.Sprintf("Activity (%dd)", n)
+			break
+		}
+	}
+	releases := false
+	for _, repo := range repos {
+		if repo.Release != nil || repo.DefaultRelease != nil || repo.NoReleases {
+			releases = true
+			break
+		}
+	}
+	if releases {
+		header = append(header, "Release")
+	}
+
 	table := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithHeader([]string{"Repo", "Status", "Last Active", "Branch", "Ahead/Behind", "Activity"}),
+		tablewriter.WithHeader(header),
 		tablewriter.WithHeaderAlignment(tw.AlignLeft),
 		tablewriter.WithAlignment(tw.Alignment{tw.AlignLeft}),
 		tablewriter.WithBorders(tw.Border{Left: tw.Off, Right: tw.Off, Top: tw.Off, Bottom: tw.Off}),
 	)
 
-	for _, repo := range result.Repos {
+	for _, repo := range groupWorktrees(repos) {
+		name := repo.Name
+		switch repo.Worktree {
+		case core.WorktreeLinked:
+			name = dim("↳ ") + name
+		case core.WorktreeSubmodule:
+			name = name + dim(" (submodule)")
+		}
+
 		status := green("✔ clean")
-		if !repo.IsClean {
+		switch {
+		case repo.Bare != nil:
+			status = cyan("◆ " + string(repo.Kind))
+			if repo.Kind == core.KindMirror {
+				status += dim(" fetched " + timeAgo(repo.Bare.LastFetch))
+			}
+		case !repo.IsClean && repo.Changes != nil:
+			status = red("✘ ") + changeSummary(repo.Changes)
+		case !repo.IsClean:
 			status = red(fmt.Sprintf("✘ %d changed", repo.ChangedFiles))
+		case repo.Changes != nil && repo.Changes.Ignored > 0:
+			status += " " + dim(fmt.Sprintf("◌%d", repo.Changes.Ignored))
+		}
+		if len(repo.Operations) > 0 {
+			status = red("⏸ "+operationShort(repo.Operations[0])) + " " + status
+		}
+		if stash := stashIndicator(repo.Stashes); stash != "" {
+			status += " " + stash
+		}
+		if repo.AtRisk {
+			status += " " + red("⚠")
 		}
 
 		branch := repo.Branch
+		if repo.Detached != nil {
+			branch = detachedLabel(repo.Detached)
+		}
 		if repo.IsGhost {
 			branch = dim(branch + " 👻")
 		}
-
-		aheadBehind := ""
-		if repo.UnpushedCommits > 0 {
-			aheadBehind += fmt.Sprintf("↑%d", repo.UnpushedCommits)
-		}
-		if repo.UnpulledCommits > 0 {
-			if aheadBehind != "" {
-				aheadBehind += " "
-			}
-			aheadBehind += fmt.Sprintf("↓%d", repo.UnpulledCommits)
+		if len(repo.Branches) > 0 {
+			branch += " " + dim(branchCounts(repo.Branches))
 		}
 
-		table.Append([]string{
-			repo.Name,
+		row := []string{
+			name,
 			status,
 			timeAgo(repo.LastCommitTime),
 			branch,
-			aheadBehind,
+			trackingCell(repo) + fetchNote(repo.Fetch),
 			sparkline(repo.DailyActivity),
-		})
+		}
+		if releases {
+			row = append(row, releaseCell(repo))
+		}
+		table.Append(row)
 	}
 
-	fmt.Printf("\n%s  Found %d repos (scanned in %s)\n\n",
-		cyan("pulse"),
-		result.TotalRepos,
-		result.ScanDuration.Round(time.Millisecond))
 	table.Render()
+}
 
-	if len(result.Errors) > 0 {
-		fmt.Printf("\n%s  %d repos had errors\n", red("!"), len(result.Errors))
-		for _, e := range result.Errors {
-			fmt.Printf("  %s: %s\n", dim(e.Path), e.Message)
+func groupWorktrees(repos []core.RepoStatus) []core.RepoStatus {
+	present := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		present[absPath(repo.Path)] = true
+	}
+
+	linked := make(map[string][]core.RepoStatus)
+	for _, repo := range repos {
+		if repo.Worktree == core.WorktreeLinked && present[repo.Parent] {
+			linked[repo.Parent] = append(linked[repo.Parent], repo)
 		}
 	}
 
-	if len(result.NonGitPaths) > 0 {
-		fmt.Printf("\n%s  %d non-git directories: %s\n",
-			dim("📁"),
-			len(result.NonGitPaths),
-			dim(strings.Join(result.NonGitPaths, ", ")))
+	grouped := make([]core.RepoStatus, 0, len(repos))
+	for _, repo := range repos {
+		if repo.Worktree == core.WorktreeLinked && present[repo.Parent] {
+			continue
+		}
+		grouped = append(grouped, repo)
+		grouped = append(grouped, linked[absPath(repo.Path)]...)
 	}
+	return grouped
+}
 
-	if result.DailyCommits != nil {
-		today := time.Now().Format("2006-01-02")
-		if count, ok := result.DailyCommits[today]; ok {
-			fmt.Printf("\n%s  %d commits today across all repos\n", cyan("📊"), count)
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+func aheadBehind(ahead, behind int) string {
+	s := ""
+	if ahead > 0 {
+		s += fmt.Sprintf("↑%d", ahead)
+	}
+	if behind > 0 {
+		if s != "" {
+			s += " "
 		}
+		s += fmt.Sprintf("↓%d", behind)
 	}
+	return s
+}
 
-	fmt.Println()
+// trackingCell names the compared refs only when they are not the usual
+// origin/<branch>, so forks and renamed upstreams stand out.
+func trackingCell(repo core.RepoStatus) string {
+	up, push := repo.Upstream, repo.Push
+	if up != nil && up.Gone {
+		return dim("upstream gone")
+	}
+	usual := "refs/remotes/origin/" + repo.Branch
+	s := aheadBehind(repo.UnpushedCommits, repo.UnpulledCommits)
+	switch {
+	case up != nil && push != nil && up.Ref != push.Ref:
+		s = strings.TrimSpace(aheadBehind(0, up.Behind) + " " + dim(shortRef(up.Ref)))
+		if pushed := aheadBehind(push.Ahead, 0); pushed != "" {
+			s += dim(" · ") + pushed + " " + dim(shortRef(push.Ref))
+		}
+	case up != nil && up.Ref != usual:
+		s = strings.TrimSpace(s + " " + dim(shortRef(up.Ref)))
+	case up == nil && push != nil && push.Ref != usual:
+		s = strings.TrimSpace(s + " " + dim(shortRef(push.Ref)))
+	}
+	return s
+}
+
+func renderUnfinished(result *core.ScanResult) {
+	switch result.Stopped {
+	case core.UnfinishedTimeout:
+		fmt.Printf("\n%s  Scan ran out of time; %d repos unfinished\n", red("⏹"), len(result.Unfinished))
+	case core.UnfinishedCancelled:
+		fmt.Printf("\n%s  Scan cancelled; %d repos unfinished\n", red("⏹"), len(result.Unfinished))
+	default:
+		fmt.Printf("\n%s  %d repos timed out\n", red("⏹"), len(result.Unfinished))
+	}
+	for _, repo := range result.Unfinished {
+		verb := "timed out"
+		if repo.Reason == core.UnfinishedCancelled {
+			verb = "cancelled"
+		}
+		if repo.Stage == "" {
+			fmt.Printf("  %-20s %s\n", repo.Name, dim(verb+" before analysis started"))
+			continue
+		}
+		fmt.Printf("  %-20s %s\n", repo.Name, dim(fmt.Sprintf("%s during %s after %s",
+			verb, repo.Stage, repo.Elapsed.Round(time.Millisecond))))
+	}
+}
+
+// fetchNote flags a failed fetch next to the counts it left stale.
+func fetchNote(fetch *core.FetchResult) string {
+	if fetch == nil || fetch.Outcome == core.FetchOK || fetch.Outcome == core.FetchNoRemote {
+		return ""
+	}
+	return " " + red("⚠ "+fetchLabel(fetch.Outcome))
+}
+
+func fetchLabel(outcome core.FetchOutcome) string {
+	switch outcome {
+	case core.FetchAuth:
+		return "auth failed"
+	case core.FetchNetwork:
+		return "network error"
+	case core.FetchTimeout:
+		return "fetch timed out"
+	case core.FetchCancelled:
+		return "fetch cancelled"
+	}
+	return "fetch failed"
+}
+
+func shortRef(ref string) string {
+	for _, prefix := range []string{"refs/remotes/", "refs/heads/"} {
+		if rest, ok := strings.CutPrefix(ref, prefix); ok {
+			return rest
+		}
+	}
+	return ref
+}
+
+// changeSummary reads like a shell prompt: +staged ~modified -deleted
+// »renamed ?untracked !unmerged ◌ignored.
+func changeSummary(c *core.WorktreeStatus) string {
+	var parts []string
+	add := func(symbol string, n int, paint func(...interface{}) string) {
+		if n > 0 {
+			parts = append(parts, paint(fmt.Sprintf("%s%d", symbol, n)))
+		}
+	}
+	add("+", c.Staged, green)
+	add("~", c.Modified, yellow)
+	add("-", c.Deleted, yellow)
+	add("»", c.Renamed, yellow)
+	add("?", c.Untracked, dim)
+	add("!", c.Unmerged, red)
+	add("◌", c.Ignored, dim)
+	return strings.Join(parts, " ")
+}
+
+// releaseCell prefers the default branch's release, since that is what ships,
+// and shows how long the oldest unreleased commit has been waiting.
+func releaseCell(repo core.RepoStatus) string {
+	release := repo.DefaultRelease
+	if release == nil {
+		release = repo.Release
+	}
+	if repo.NoReleases {
+		return dim("no releases")
+	}
+	if release == nil {
+		return ""
+	}
+	if release.CommitsSince == 0 {
+		return release.Tag
+	}
+	return fmt.Sprintf("%s %s %s", release.Tag,
+		yellow(fmt.Sprintf("+%d", release.CommitsSince)),
+		dim("oldest "+timeAgo(release.OldestUnreleased)))
+}
+
+func detachedLabel(d *core.DetachedHead) string {
+	at := d.Commit
+	switch {
+	case d.Tag != "" && d.Distance == 0:
+		at = d.Tag
+	case d.Tag != "":
+		at = fmt.Sprintf("%s+%d", d.Tag, d.Distance)
+	}
+	s := yellow("detached @ " + at)
+	if d.Unreachable > 0 {
+		s += " " + red(fmt.Sprintf("%d unreachable", d.Unreachable))
+	} else if len(d.ContainedIn) > 0 {
+		s += " " + dim("in "+d.ContainedIn[0])
+		if len(d.ContainedIn) > 1 {
+			s += dim(fmt.Sprintf(" +%d", len(d.ContainedIn)-1))
+		}
+	}
+	return s
+}
+
+func operationShort(op core.Operation) string {
+	if op.Total > 0 {
+		return fmt.Sprintf("%s %d/%d", op.Kind, op.Step, op.Total)
+	}
+	return string(op.Kind)
+}
+
+func operationLong(op core.Operation) string {
+	s := string(op.Kind)
+	if op.Branch != "" {
+		s += " of " + op.Branch
+	}
+	switch {
+	case op.Total > 0:
+		s += fmt.Sprintf(", step %d of %d", op.Step, op.Total)
+	case op.Kind == core.OpBisect:
+		s += fmt.Sprintf(", %d steps marked", op.Step)
+	}
+	if !op.Started.IsZero() {
+		s += dim(", started " + timeAgo(op.Started))
+	}
+	return s
+}
+
+func stashIndicator(stashes []core.Stash) string {
+	if len(stashes) == 0 {
+		return ""
+	}
+	s := fmt.Sprintf("≡%d", len(stashes))
+	for _, st := range stashes {
+		if st.Stale {
+			return red(s)
+		}
+	}
+	return dim(s)
+}
+
+func rootTotals(root core.RootSummary) string {
+	parts := []string{fmt.Sprintf("%d repos", root.TotalRepos)}
+	if root.DirtyRepos > 0 {
+		parts = append(parts, fmt.Sprintf("%d dirty", root.DirtyRepos))
+	}
+	if ab := aheadBehind(root.UnpushedCommits, root.UnpulledCommits); ab != "" {
+		parts = append(parts, ab)
+	}
+	if root.GhostRepos > 0 {
+		parts = append(parts, fmt.Sprintf("%d 👻", root.GhostRepos))
+	}
+	if root.AtRiskRepos > 0 {
+		parts = append(parts, fmt.Sprintf("%d at risk", root.AtRiskRepos))
+	}
+	if len(root.NonGitPaths) > 0 {
+		parts = append(parts, fmt.Sprintf("%d non-git", len(root.NonGitPaths)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func nonGitDetails(d core.NonGitDir) string {
+	kind := dim(string(d.Kind))
+	if d.LikelyProject() {
+		kind = red(string(d.Kind))
+	}
+	parts := []string{kind}
+	if len(d.Manifests) > 0 {
+		parts = append(parts, strings.Join(d.Manifests, ", "))
+	} else if d.SourceFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d source files", d.SourceFiles))
+	}
+	size := formatBytes(d.SizeBytes)
+	if d.Partial {
+		size = "≥" + size
+	}
+	parts = append(parts, dim(size))
+	if !d.LastModified.IsZero() {
+		parts = append(parts, dim(timeAgo(d.LastModified)))
+	}
+	return strings.Join(parts, dim(" · "))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
 }
 
 func RenderDetail(result *core.ScanResult) {
 	for i := len(result.Repos) - 1; i >= 0; i-- {
 		repo := result.Repos[i]
-		if len(repo.RecentCommits) == 0 {
+		var files []core.WorktreeFile
+		if repo.Changes != nil {
+			files = repo.Changes.Files
+		}
+		release := repo.DefaultRelease
+		if release == nil {
+			release = repo.Release
+		}
+		if release != nil && len(release.Subjects) == 0 {
+			release = nil
+		}
+		if len(repo.RecentCommits) == 0 && len(repo.Stashes) == 0 && len(files) == 0 && release == nil {
 			continue
 		}
 
@@ -118,16 +623,153 @@ func RenderDetail(result *core.ScanResult) {
 				dim(timeAgo(c.Timestamp)))
 		}
 
-		if repo.LinesChanged != nil {
-			fmt.Printf("  %s +%d %s -%d (last 7 days)\n",
+		if lines := repo.LinesChanged; lines != nil {
+			fmt.Printf("  %s +%d %s -%d (last %d days)%s\n",
 				dim("lines:"),
-				repo.LinesChanged.Added,
+				lines.Added,
 				dim("/"),
-				repo.LinesChanged.Removed)
+				lines.Removed,
+				int(lines.Period.Hours()/24),
+				languageBreakdown(lines))
+		}
+
+		if release != nil {
+			fmt.Printf("  %s\n", dim(fmt.Sprintf("unreleased since %s (%s), %d commits:",
+				release.Tag, timeAgo(release.Date), release.CommitsSince)))
+			for _, subject := range release.Subjects {
+				fmt.Printf("    %s %s\n", dim("•"), subject)
+			}
+			if more := release.CommitsSince - len(release.Subjects); more > 0 {
+				fmt.Printf("    %s\n", dim(fmt.Sprintf("… and %d more", more)))
+			}
+		}
+
+		for _, f := range files {
+			path := f.Path
+			if f.OrigPath != "" {
+				path = f.OrigPath + " → " + f.Path
+			}
+			fmt.Printf("  %s %s\n", yellow(f.Code), path)
+		}
+
+		for _, st := range repo.Stashes {
+			age := dim(timeAgo(st.Created))
+			if st.Stale {
+				age = red(timeAgo(st.Created))
+			}
+			fmt.Printf("  %s %s %s %s %s\n",
+				dim(st.Ref),
+				st.Message,
+				dim("on "+st.Branch),
+				dim(fmt.Sprintf("(%d files)", st.Files)),
+				age)
 		}
 	}
 }
 
+// RenderProbes prints what probes outside the built-in set reported, and
+// any probe that failed, per repo.
+func RenderProbes(result *core.ScanResult) {
+	for i := len(result.Repos) - 1; i >= 0; i-- {
+		repo := result.Repos[i]
+		if len(repo.Extra) == 0 && len(repo.ProbeErrors) == 0 {
+			continue
+		}
+
+		fmt.Printf("\n%s %s\n", cyan("─────"), repo.Name)
+		for _, name := range sortedKeys(repo.Extra) {
+			value, err := json.Marshal(repo.Extra[name])
+			if err != nil {
+				value = []byte(fmt.Sprint(repo.Extra[name]))
+			}
+			fmt.Printf("  %s %s\n", dim(name+":"), value)
+		}
+		for _, name := range sortedKeys(repo.ProbeErrors) {
+			fmt.Printf("  %s %s\n", dim(name+":"), red(repo.ProbeErrors[name]))
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RenderProbeList prints every registered probe in run order.
+func RenderProbeList(probes []core.ProbeInfo) {
+	for _, p := range probes {
+		state := green("on ")
+		if !p.Enabled {
+			state = dim("off")
+		}
+		var notes []string
+		if len(p.Deps) > 0 {
+			notes = append(notes, "after "+strings.Join(p.Deps, ", "))
+		}
+		if !p.BuiltIn {
+			notes = append(notes, "custom")
+		}
+		fmt.Printf("%s %-16s %-10s %s\n", state, p.Name, dim(string(p.Cost)), dim(strings.Join(notes, " · ")))
+	}
+}
+
+func RenderBranches(result *core.ScanResult) {
+	for i := len(result.Repos) - 1; i >= 0; i-- {
+		repo := result.Repos[i]
+		if len(repo.Branches) == 0 {
+			continue
+		}
+
+		fmt.Printf("\n%s %s  %s\n", cyan("─────"), repo.Name, dim(branchCounts(repo.Branches)))
+		for _, b := range repo.Branches {
+			marker := " "
+			if b.Current {
+				marker = green("*")
+			}
+			var notes []string
+			switch {
+			case b.NeverPushed:
+				notes = append(notes, red("never pushed"))
+			case b.Unpushed > 0:
+				notes = append(notes, red(fmt.Sprintf("%d unpushed", b.Unpushed)))
+			}
+			if b.Upstream != nil && b.Upstream.Behind > 0 {
+				notes = append(notes, fmt.Sprintf("↓%d %s", b.Upstream.Behind, dim(shortRef(b.Upstream.Ref))))
+			}
+			if b.Merged {
+				notes = append(notes, green("merged"))
+			} else if b.Default != nil {
+				notes = append(notes, dim(fmt.Sprintf("%s vs %s", aheadBehind(b.Default.Ahead, b.Default.Behind), shortRef(b.Default.Ref))))
+			}
+			fmt.Printf("  %s %-28s %-10s %s\n", marker, b.Name, dim(timeAgo(b.LastCommitTime)), strings.Join(notes, dim(" · ")))
+		}
+	}
+}
+
+func branchCounts(branches []core.BranchStatus) string {
+	var unpushed, merged int
+	for _, b := range branches {
+		if b.Unpushed > 0 {
+			unpushed++
+		}
+		if b.Merged {
+			merged++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d branches", len(branches))}
+	if unpushed > 0 {
+		parts = append(parts, fmt.Sprintf("%d unpushed", unpushed))
+	}
+	if merged > 0 {
+		parts = append(parts, fmt.Sprintf("%d merged", merged))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func RenderJSON(result *core.ScanResult) {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
@@ -135,11 +777,16 @@ func RenderJSON(result *core.ScanResult) {
 }
 
 type parsedSpans struct {
-	findReposDur time.Duration
-	processDur   time.Duration
-	processStart time.Time
-	analyzes     []analyzeInfo
-	children     map[trace.SpanID][]childSpan
+	findReposDur   time.Duration
+	findReposStart time.Time
+	cacheEnabled   bool
+	cacheHits      int64
+	cacheMisses    int64
+	processDur     time.Duration
+	processStart   time.Time
+	analyzes       []analyzeInfo
+	fetches        []analyzeInfo
+	children       map[trace.SpanID][]childSpan
 }
 
 type analyzeInfo struct {
@@ -168,23 +815,39 @@ func parseSpans(spans []sdktrace.ReadOnlySpan) parsedSpans {
 		switch s.Name() {
 		case "find_repos":
 			p.findReposDur = dur
+			p.findReposStart = s.StartTime()
+			for _, attr := range s.Attributes() {
+				switch attr.Key {
+				case "cache.enabled":
+					p.cacheEnabled = attr.Value.AsBool()
+				case "cache.hits":
+					p.cacheHits = attr.Value.AsInt64()
+				case "cache.misses":
+					p.cacheMisses = attr.Value.AsInt64()
+				}
+			}
 		case "process":
 			p.processDur = dur
 			p.processStart = s.StartTime()
-		case "analyze":
+		case "analyze", "fetch":
 			repo := ""
 			for _, attr := range s.Attributes() {
 				if attr.Key == attribute.Key("repo") {
 					repo = attr.Value.AsString()
 				}
 			}
-			p.analyzes = append(p.analyzes, analyzeInfo{
+			info := analyzeInfo{
 				repo:   repo,
 				start:  s.StartTime(),
 				end:    s.EndTime(),
 				dur:    dur,
 				spanID: s.SpanContext().SpanID(),
-			})
+			}
+			if s.Name() == "fetch" {
+				p.fetches = append(p.fetches, info)
+			} else {
+				p.analyzes = append(p.analyzes, info)
+			}
 		default:
 			parent := s.Parent()
 			if !parent.IsValid() {
@@ -216,7 +879,21 @@ func RenderTimings(exp *tracing.CollectingExporter) {
 
 	fmt.Printf("\n%s  Performance Breakdown\n", cyan("⏱"))
 	fmt.Printf("  %-20s %s\n", "Directory scan:", p.findReposDur.Round(time.Millisecond))
+	if p.cacheEnabled {
+		total := p.cacheHits + p.cacheMisses
+		rate := 0.0
+		if total > 0 {
+			rate = float64(p.cacheHits) / float64(total) * 100
+		}
+		fmt.Printf("  %-20s %d hits, %d misses (%.0f%%)\n", "Discovery cache:", p.cacheHits, p.cacheMisses, rate)
+	}
+	if len(p.fetches) > 0 {
+		fmt.Printf("  %-20s %s\n", "Fetch:", fetchSummary(p.fetches))
+	}
 	fmt.Printf("  %-20s %s\n", "Analysis (total):", p.processDur.Round(time.Millisecond))
+	if overlap := p.overlap(); overlap > 0 {
+		fmt.Printf("  %-20s %s\n", "Overlap:", overlap.Round(time.Millisecond))
+	}
 
 	if len(p.analyzes) == 0 {
 		fmt.Println()
@@ -249,19 +926,61 @@ func RenderTimings(exp *tracing.CollectingExporter) {
 	fmt.Println()
 }
 
+// fetchSummary gives the fetch stage's wall time and its slowest repo.
+func fetchSummary(fetches []analyzeInfo) string {
+	start, end := fetches[0].start, fetches[0].end
+	slowest := fetches[0]
+	for _, f := range fetches[1:] {
+		if f.start.Before(start) {
+			start = f.start
+		}
+		if f.end.After(end) {
+			end = f.end
+		}
+		if f.dur > slowest.dur {
+			slowest = f
+		}
+	}
+	return fmt.Sprintf("%s for %d repos, slowest %s (%s)", end.Sub(start).Round(time.Millisecond),
+		len(fetches), slowest.repo, slowest.dur.Round(time.Millisecond))
+}
+
 const waterfallWidth = 50
 
+func (p parsedSpans) overlap() time.Duration {
+	findEnd := p.findReposStart.Add(p.findReposDur)
+	start := p.processStart
+	if p.findReposStart.After(start) {
+		start = p.findReposStart
+	}
+	if d := findEnd.Sub(start); d > 0 && !p.findReposStart.IsZero() {
+		return d
+	}
+	return 0
+}
+
 func renderWaterfall(p parsedSpans) {
 	if len(p.analyzes) == 0 {
 		return
 	}
 
-	totalDur := p.processDur
+	origin := p.processStart
+	end := p.processStart.Add(p.processDur)
+	if !p.findReposStart.IsZero() {
+		if p.findReposStart.Before(origin) {
+			origin = p.findReposStart
+		}
+		if findEnd := p.findReposStart.Add(p.findReposDur); findEnd.After(end) {
+			end = findEnd
+		}
+	}
+
+	totalDur := end.Sub(origin)
 	if totalDur == 0 {
 		return
 	}
 
-	maxNameLen := 0
+	maxNameLen := len(discoveryLabel)
 	for _, a := range p.analyzes {
 		if len(a.repo) > maxNameLen {
 			maxNameLen = len(a.repo)
@@ -271,34 +990,45 @@ func renderWaterfall(p parsedSpans) {
 		maxNameLen = 20
 	}
 
-	yellow := color.New(color.FgYellow).SprintFunc()
-
 	fmt.Printf("\n  %s  Waterfall (%s)\n", cyan("▸"), totalDur.Round(time.Millisecond))
 
-	for _, a := range p.analyzes {
-		offset := a.start.Sub(p.processStart)
-		startCol := int(float64(offset) / float64(totalDur) * waterfallWidth)
-		barLen := int(float64(a.dur) / float64(totalDur) * waterfallWidth)
-		if barLen < 1 {
-			barLen = 1
-		}
-		if startCol+barLen > waterfallWidth {
-			barLen = waterfallWidth - startCol
-		}
-		if startCol < 0 {
-			startCol = 0
-		}
+	if !p.findReposStart.IsZero() {
+		bar := waterfallBar(p.findReposStart.Sub(origin), p.findReposDur, totalDur, cyan)
+		fmt.Printf("  %-*s %s %s\n", maxNameLen, discoveryLabel, bar, dim(p.findReposDur.Round(time.Millisecond).String()))
+	}
 
+	for _, a := range p.analyzes {
 		name := a.repo
 		if len(name) > maxNameLen {
 			name = name[:maxNameLen-1] + "…"
 		}
 
-		bar := strings.Repeat(" ", startCol) + yellow(strings.Repeat("█", barLen)) + strings.Repeat(" ", waterfallWidth-startCol-barLen)
+		bar := waterfallBar(a.start.Sub(origin), a.dur, totalDur, yellow)
 		fmt.Printf("  %-*s %s %s\n", maxNameLen, name, bar, dim(a.dur.Round(time.Millisecond).String()))
 	}
 }
 
+const discoveryLabel = "(discovery)"
+
+func waterfallBar(offset, dur, totalDur time.Duration, paint func(a ...interface{}) string) string {
+	startCol := int(float64(offset) / float64(totalDur) * waterfallWidth)
+	barLen := int(float64(dur) / float64(totalDur) * waterfallWidth)
+	if barLen < 1 {
+		barLen = 1
+	}
+	if startCol < 0 {
+		startCol = 0
+	}
+	if startCol+barLen > waterfallWidth {
+		startCol = waterfallWidth - barLen
+		if startCol < 0 {
+			startCol, barLen = 0, waterfallWidth
+		}
+	}
+
+	return strings.Repeat(" ", startCol) + paint(strings.Repeat("█", barLen)) + strings.Repeat(" ", waterfallWidth-startCol-barLen)
+}
+
 func renderSpanTree(p parsedSpans, slowestIdx int) {
 	slowest := p.analyzes[slowestIdx]
 
@@ -365,7 +1095,10 @@ func renderChildren(p parsedSpans, parentID trace.SpanID, rootStart time.Time, t
 
 var sparkBlocks = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
 
+// sparkline draws one block per day, summing days into wider buckets once the
+// window is longer than maxSparkWidth so the column stays narrow.
 func sparkline(values []int) string {
+	values = bucketValues(values, maxSparkWidth)
 	if len(values) == 0 {
 		return dim("▁▁▁▁▁▁▁")
 	}
@@ -378,7 +1111,7 @@ func sparkline(values []int) string {
 	}
 
 	if max == 0 {
-		return dim("▁▁▁▁▁▁▁")
+		return dim(strings.Repeat("▁", len(values)))
 	}
 
 	var b strings.Builder
@@ -389,6 +1122,21 @@ func sparkline(values []int) string {
 	return b.String()
 }
 
+const maxSparkWidth = 30
+
+func bucketValues(values []int, width int) []int {
+	if len(values) <= width {
+		return values
+	}
+	size := (len(values) + width - 1) / width
+	buckets := make([]int, (len(values)+size-1)/size)
+	offset := len(buckets)*size - len(values)
+	for i, v := range values {
+		buckets[(i+offset)/size] += v
+	}
+	return buckets
+}
+
 func timeAgo(t time.Time) string {
 	if t.IsZero() {
 		return "never"
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"sort"
+	"time"
+)
+
+// tallyOrder is the order repos claim shared commits in: main worktrees
+// before linked ones, then by path.
+func tallyOrder(statuses []RepoStatus) []int {
+	order := make([]int, len(statuses))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := statuses[order[i]], statuses[order[j]]
+		if linkedA, linkedB := a.Worktree == WorktreeLinked, b.Worktree == WorktreeLinked; linkedA != linkedB {
+			return linkedB
+		}
+		return a.Path < b.Path
+	})
+	return order
+}
+
+// tallyActivity adds up each repo's DailyActivity, whose last entry is today,
+// into tree-wide per-day, per-repo and per-weekday totals. Worktrees of one
+// repository share a row, and a commit reached from several worktrees or
+// clones is counted once, for the first repo to list it: main worktrees come
+// before linked ones, then repos are taken in path order.
+func tallyActivity(statuses []RepoStatus, today time.Time) *Activity {
+	window := 0
+	for _, st := range statuses {
+		window = max(window, len(st.DailyActivity))
+	}
+	if window == 0 {
+		return nil
+	}
+
+	activity := &Activity{Days: make([]DayActivity, window)}
+	first := today.AddDate(0, 0, -(window - 1))
+	for i := range activity.Days {
+		activity.Days[i].Date = first.AddDate(0, 0, i).Format(dateLayout)
+	}
+
+	type row struct {
+		name, path string
+		days       []int
+	}
+	var rows []*row
+	byRepo := make(map[string]*row)
+	seen := make(map[string]bool)
+	for _, i := range tallyOrder(statuses) {
+		st := statuses[i]
+		key := st.commonDir
+		if key == "" {
+			key = st.Path
+		}
+		r := byRepo[key]
+		if r == nil {
+			r = &row{name: st.Name, path: st.Path, days: make([]int, window)}
+			byRepo[key] = r
+			rows = append(rows, r)
+		}
+
+		offset := window - len(st.DailyActivity)
+		for hash, day := range st.activityCommits {
+			if !seen[hash] {
+				seen[hash] = true
+				r.days[offset+day]++
+			}
+		}
+	}
+
+	for _, r := range rows {
+		repoTotal := 0
+		for i, n := range r.days {
+			if n == 0 {
+				continue
+			}
+			day := &activity.Days[i]
+			day.Commits += n
+			day.Repos = append(day.Repos, RepoActivity{Name: r.name, Path: r.path, Commits: n})
+			activity.Weekdays[first.AddDate(0, 0, i).Weekday()] += n
+			repoTotal += n
+		}
+		if repoTotal > 0 {
+			activity.Repos = append(activity.Repos, RepoActivity{Name: r.name, Path: r.path, Commits: repoTotal})
+			activity.Total += repoTotal
+		}
+	}
+	activity.Today = activity.Days[window-1].Commits
+
+	byCommits := func(repos []RepoActivity) {
+		sort.SliceStable(repos, func(i, j int) bool { return repos[i].Commits > repos[j].Commits })
+	}
+	byCommits(activity.Repos)
+	for i := range activity.Days {
+		byCommits(activity.Days[i].Repos)
+	}
+	return activity
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withCommits fills in DailyActivity and a made-up hash for each commit.
+func withCommits(st RepoStatus, prefix string, days []int) RepoStatus {
+	st.DailyActivity = days
+	st.activityCommits = make(map[string]int)
+	for i, n := range days {
+		for j := 0; j < n; j++ {
+			st.activityCommits[fmt.Sprintf("%s-%d-%d", prefix, i, j)] = i
+		}
+	}
+	return st
+}
+
+func TestTallyActivity(t *testing.T) {
+	today := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC) // a Friday
+	statuses := []RepoStatus{
+		withCommits(RepoStatus{Name: "api", Path: "/src/api"}, "api", []int{0, 2, 0, 3}),
+		withCommits(RepoStatus{Name: "web", Path: "/src/web"}, "web", []int{1, 0, 4, 1}),
+		withCommits(RepoStatus{Name: "idle", Path: "/src/idle"}, "idle", []int{0, 0, 0, 0}),
+	}
+
+	activity := tallyActivity(statuses, today)
+	if activity.Days[0].Date != "2026-10-13" || activity.Days[3].Date != "2026-10-16" {
+		t.Fatalf("window = %s..%s, want 2026-10-13..2026-10-16", activity.Days[0].Date, activity.Days[3].Date)
+	}
+	if activity.Total != 11 || activity.Today != 4 {
+		t.Errorf("total = %d, today = %d; want 11 and 4", activity.Total, activity.Today)
+	}
+	if len(activity.Repos) != 2 || activity.Repos[0].Name != "web" || activity.Repos[0].Commits != 6 {
+		t.Errorf("repos = %+v, want web (6) first and idle left out", activity.Repos)
+	}
+	if today := activity.Days[3].Repos; len(today) != 2 || today[0].Name != "api" {
+		t.Errorf("today's repos = %+v, want api then web", today)
+	}
+	if want := [7]int{2: 1, 3: 2, 4: 4, 5: 4}; activity.Weekdays != want {
+		t.Errorf("weekdays = %v, want %v", activity.Weekdays, want)
+	}
+
+	// A second clone of web reaching the same commits plus one of its own.
+	clone := withCommits(RepoStatus{Name: "web-copy", Path: "/src/web-copy"}, "web", []int{1, 0, 4, 1})
+	clone.activityCommits["local"] = 3
+	clone.DailyActivity[3]++
+	activity = tallyActivity(append(statuses, clone), today)
+	if activity.Total != 12 || activity.Today != 5 {
+		t.Errorf("with clone: total = %d, today = %d; want 12 and 5", activity.Total, activity.Today)
+	}
+
+	if tallyActivity(nil, today) != nil {
+		t.Error("expected no tally without repos")
+	}
+}
+
+func TestTallyActivityWorktrees(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	main := filepath.Join(root, "repo")
+	linked := filepath.Join(root, "repo-feature")
+	runGit(t, root, "init", "-q", "-b", "main", main)
+	runGit(t, main, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, main, "worktree", "add", "-q", "-b", "feature", linked)
+	runGit(t, linked, "commit", "-q", "--allow-empty", "-m", "feature work")
+	runGit(t, main, "branch", "idea")
+	runGit(t, main, "checkout", "-q", "idea")
+	runGit(t, main, "commit", "-q", "--allow-empty", "-m", "side branch")
+	runGit(t, main, "checkout", "-q", "main")
+
+	analyzer := NewAnalyzer(ScanConfig{})
+	var statuses []RepoStatus
+	for _, path := range []string{linked, main} {
+		status, err := analyzer.Analyze(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := status.DailyActivity[len(status.DailyActivity)-1]; got != 3 {
+			t.Errorf("%s: %d commits today, want 3 across all branches", status.Name, got)
+		}
+		statuses = append(statuses, *status)
+	}
+
+	activity := tallyActivity(statuses, analyzer.clock.today())
+	if activity.Total != 3 || activity.Today != 3 {
+		t.Errorf("total = %d, today = %d; want each commit counted once", activity.Total, activity.Today)
+	}
+	if len(activity.Repos) != 1 || activity.Repos[0].Path != main {
+		t.Errorf("repos = %+v, want one row for the main worktree", activity.Repos)
+	}
+}
@@ -1,15 +1,19 @@
 package core
 
 import (
-	"bytes"
 	"context"
-	"os/exec"
+	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/guidefari/pulse/internal/tracing"
 	"go.opentelemetry.io/otel/attribute"
@@ -18,27 +22,92 @@ import (
 
 type Analyzer struct {
 	detailMode     bool
-	fetch          bool
+	branches       bool
+	ignoredFiles   bool
+	authors        AuthorFilter
+	activityDays   int
+	heatmap        bool
+	releases       bool
+	clock          dayClock
+	linesExclude   []gitignore.Pattern
+	repoTimeout    time.Duration
+	includeMerges  bool
 	ghostThreshold time.Duration
+
+	probes    []Probe
+	probeInfo []ProbeInfo
+	probeErr  error
+
+	// roots caches root commits across scans; nil walks every time.
+	roots *rootCache
 }
 
-func NewAnalyzer(detailMode bool, fetch bool, ghostThreshold time.Duration) *Analyzer {
-	return &Analyzer{
-		detailMode:     detailMode,
-		fetch:          fetch,
-		ghostThreshold: ghostThreshold,
+func NewAnalyzer(config ScanConfig) *Analyzer {
+	if config.GhostThreshold <= 0 {
+		config.GhostThreshold = DefaultGhostThreshold
+	}
+	if config.ActivityDays <= 0 {
+		config.ActivityDays = DefaultActivityDays
+	}
+	a := &Analyzer{
+		detailMode:     config.DetailMode,
+		branches:       config.Branches,
+		ignoredFiles:   config.IgnoredFiles,
+		authors:        config.Authors,
+		activityDays:   config.ActivityDays,
+		heatmap:        config.Heatmap,
+		releases:       config.Releases || config.UnreleasedOlderThan > 0,
+		clock:          newDayClock(config),
+		linesExclude:   parseExcludes(config.LinesExclude),
+		repoTimeout:    config.RepoTimeout,
+		includeMerges:  config.IncludeMerges,
+		ghostThreshold: config.GhostThreshold,
 	}
+	a.probes, a.probeInfo, a.probeErr = planProbes(append(a.builtinProbes(), config.Probes...),
+		config.EnableProbes, config.DisableProbes)
+	return a
+}
+
+// Probes lists every registered probe in the order they run, or the reason
+// the probe configuration is invalid.
+func (a *Analyzer) Probes() ([]ProbeInfo, error) {
+	return a.probeInfo, a.probeErr
 }
 
 func (a *Analyzer) Analyze(ctx context.Context, repoPath string) (*RepoStatus, error) {
+	if a.probeErr != nil {
+		return nil, a.probeErr
+	}
+	if a.repoTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.repoTimeout)
+		defer cancel()
+	}
 	ctx, span := tracing.Tracer().Start(ctx, "analyze",
 		trace.WithAttributes(attribute.String("repo", filepath.Base(repoPath))),
 	)
 	defer span.End()
+	start := time.Now()
+
+	// stage runs one step under its own span. Once the deadline passes or the
+	// scan is cancelled, the step that was running is recorded and the rest
+	// are skipped.
+	var stopped string
+	stage := func(name string, fn func()) {
+		if stopped != "" {
+			return
+		}
+		_, stageSpan := tracing.Tracer().Start(ctx, name)
+		fn()
+		stageSpan.End()
+		if ctx.Err() != nil {
+			stopped = name
+		}
+	}
 
-	_, plainSpan := tracing.Tracer().Start(ctx, "plain_open")
-	repo, err := git.PlainOpen(repoPath)
-	plainSpan.End()
+	var repo *git.Repository
+	var err error
+	stage("plain_open", func() { repo, err = openRepo(repoPath) })
 	if err != nil {
 		return nil, err
 	}
@@ -47,46 +116,133 @@ func (a *Analyzer) Analyze(ctx context.Context, repoPath string) (*RepoStatus, e
 		Name: filepath.Base(repoPath),
 		Path: repoPath,
 	}
+	authors, err := a.authors.authorsFor(ctx, repoPath)
+	status.Authors = authors.identities()
+	status.authorsErr = err
+	probeRepo := &ProbeRepo{Path: repoPath, Repo: repo, Status: status, authors: authors}
+
+	for _, probe := range a.probes {
+		if fp, ok := probe.(*funcProbe); ok && !fp.applies(status) {
+			continue
+		}
+		stage(probe.Name(), func() {
+			result, err := probe.Run(ctx, probeRepo)
+			if err != nil {
+				if status.ProbeErrors == nil {
+					status.ProbeErrors = make(map[string]string)
+				}
+				status.ProbeErrors[probe.Name()] = err.Error()
+				return
+			}
+			if result != nil {
+				if status.Extra == nil {
+					status.Extra = make(map[string]any)
+				}
+				status.Extra[probe.Name()] = result
+			}
+		})
+	}
 
-	_, branchSpan := tracing.Tracer().Start(ctx, "branch")
-	a.analyzeBranch(repo, status)
-	branchSpan.End()
+	if stopped != "" {
+		return nil, &stopError{stage: stopped, reason: stopReason(ctx.Err()), elapsed: time.Since(start)}
+	}
 
-	_, wtSpan := tracing.Tracer().Start(ctx, "worktree_status")
-	a.analyzeWorktree(repoPath, status)
-	wtSpan.End()
+	status.AtRisk = atRisk(status)
 
-	_, lcSpan := tracing.Tracer().Start(ctx, "last_commit")
-	a.analyzeLastCommit(repo, status)
-	lcSpan.End()
+	return status, nil
+}
 
-	if a.fetch {
-		_, fetchSpan := tracing.Tracer().Start(ctx, "fetch")
-		a.fetchRemote(repoPath)
-		fetchSpan.End()
+// stopError reports an analysis cut short by its deadline or by cancellation.
+type stopError struct {
+	stage   string
+	reason  UnfinishedReason
+	elapsed time.Duration
+}
+
+func (e *stopError) Error() string {
+	return fmt.Sprintf("%s during %s after %s", e.reason, e.stage, e.elapsed.Round(time.Millisecond))
+}
+
+func stopReason(err error) UnfinishedReason {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return UnfinishedTimeout
 	}
+	return UnfinishedCancelled
+}
 
-	_, rsSpan := tracing.Tracer().Start(ctx, "remote_status")
-	a.analyzeRemoteStatus(repo, status)
-	rsSpan.End()
+func (a *Analyzer) analyzeKind(repo *git.Repository, status *RepoStatus) {
+	status.Kind = KindWorking
 
-	if a.detailMode {
-		_, rcSpan := tracing.Tracer().Start(ctx, "recent_commits")
-		a.analyzeRecentCommits(repo, status)
-		rcSpan.End()
+	cfg, err := repo.Config()
+	if err != nil || !cfg.Core.IsBare {
+		return
+	}
 
-		_, lcSpan := tracing.Tracer().Start(ctx, "lines_changed")
-		a.analyzeLinesChanged(repo, status)
-		lcSpan.End()
+	status.Kind = KindBare
+	for _, remote := range cfg.Remotes {
+		if remote.Mirror {
+			status.Kind = KindMirror
+			return
+		}
 	}
+}
 
-	_, actSpan := tracing.Tracer().Start(ctx, "daily_activity")
-	a.analyzeDailyActivity(repo, status)
-	actSpan.End()
+func (a *Analyzer) analyzeBare(repo *git.Repository, repoPath string, status *RepoStatus) {
+	status.IsClean = true
+	info := &BareInfo{}
 
-	status.IsGhost = time.Since(status.LastCommitTime) > a.ghostThreshold
+	if branches, err := repo.Branches(); err == nil {
+		branches.ForEach(func(*plumbing.Reference) error {
+			info.Branches++
+			return nil
+		})
+	}
 
-	return status, nil
+	if cfg, err := repo.Config(); err == nil {
+		for _, remote := range cfg.Remotes {
+			if remote.Mirror && len(remote.URLs) > 0 {
+				info.MirrorRemote = remote.URLs[0]
+				break
+			}
+		}
+	}
+
+	// A mirror that has only been cloned has no FETCH_HEAD yet; the clone
+	// wrote its refs, so their mtime is when it was last brought up to date.
+	marks := []string{"FETCH_HEAD"}
+	if info.MirrorRemote != "" {
+		marks = append(marks, "packed-refs", "refs")
+	}
+	for _, name := range marks {
+		if fi, err := os.Stat(filepath.Join(repoPath, name)); err == nil {
+			info.LastFetch = fi.ModTime()
+			break
+		}
+	}
+
+	status.Bare = info
+}
+
+func (a *Analyzer) analyzeLayout(repoPath string, status *RepoStatus) {
+	status.Worktree = WorktreeMain
+
+	dotGit := filepath.Join(repoPath, ".git")
+	gitDir, ok := readGitFile(dotGit)
+	if !ok {
+		return
+	}
+
+	if common, ok := readCommonDir(gitDir); ok {
+		status.Worktree = WorktreeLinked
+		status.Parent = worktreeOf(common)
+		return
+	}
+
+	super, superGitDir, ok := findSuperproject(repoPath)
+	if ok && isWithin(gitDir, filepath.Join(superGitDir, "modules")) {
+		status.Worktree = WorktreeSubmodule
+		status.Parent = super
+	}
 }
 
 func (a *Analyzer) analyzeBranch(repo *git.Repository, status *RepoStatus) {
@@ -98,23 +254,24 @@ func (a *Analyzer) analyzeBranch(repo *git.Repository, status *RepoStatus) {
 	status.Branch = head.Name().Short()
 }
 
-func (a *Analyzer) fetchRemote(repoPath string) {
-	exec.Command("git", "-C", repoPath, "fetch", "--quiet").Run()
-}
-
-func (a *Analyzer) analyzeWorktree(repoPath string, status *RepoStatus) {
-	out, err := exec.Command("git", "-C", repoPath, "status", "--porcelain").Output()
+func (a *Analyzer) analyzeWorktree(ctx context.Context, repoPath string, status *RepoStatus) {
+	args := []string{"-C", repoPath, "status", "--porcelain=v2", "--branch", "-z"}
+	if a.ignoredFiles {
+		args = append(args, "--ignored")
+	}
+	out, err := gitCommand(ctx, args...).Output()
 	if err != nil {
 		return
 	}
 
-	lines := bytes.Count(bytes.TrimRight(out, "\n"), []byte("\n"))
-	if len(out) > 0 {
-		lines++
-	}
+	changes, branch, changed := parsePorcelainV2(out, a.detailMode)
+	status.IsClean = changed == 0
+	status.ChangedFiles = changed
+	status.Changes = &changes
 
-	status.IsClean = len(out) == 0
-	status.ChangedFiles = lines
+	if status.Branch == "unknown" && branch.initial && branch.head != "" {
+		status.Branch = branch.head
+	}
 }
 
 func (a *Analyzer) analyzeLastCommit(repo *git.Repository, status *RepoStatus) {
@@ -131,48 +288,63 @@ func (a *Analyzer) analyzeLastCommit(repo *git.Repository, status *RepoStatus) {
 	status.LastCommitTime = commit.Author.When
 }
 
-func (a *Analyzer) analyzeRemoteStatus(repo *git.Repository, status *RepoStatus) {
+func (a *Analyzer) analyzeRemoteStatus(ctx context.Context, repo *git.Repository, repoPath string, status *RepoStatus) {
 	head, err := repo.Head()
 	if err != nil || !head.Name().IsBranch() {
 		return
 	}
 
-	branchName := head.Name().Short()
-	remoteBranch := plumbing.NewRemoteReferenceName("origin", branchName)
+	tracking := trackingRefs(ctx, repoPath, head.Name().String())[head.Name().String()]
+	status.Upstream = compareRef(ctx, repo, head.Hash(), tracking.upstream)
+	status.Push = compareRef(ctx, repo, head.Hash(), tracking.pushRef(repo, head.Name()))
 
-	remoteRef, err := repo.Reference(remoteBranch, true)
-	if err != nil {
-		return
+	if status.Push != nil {
+		status.UnpushedCommits = status.Push.Ahead
+	} else if status.Upstream != nil {
+		status.UnpushedCommits = status.Upstream.Ahead
 	}
-
-	localHash := head.Hash()
-	remoteHash := remoteRef.Hash()
-
-	if localHash == remoteHash {
-		return
+	if status.Upstream != nil {
+		status.UnpulledCommits = status.Upstream.Behind
+	} else if status.Push != nil {
+		status.UnpulledCommits = status.Push.Behind
 	}
+}
 
-	localCommit, err := repo.CommitObject(localHash)
-	if err != nil {
-		return
-	}
-	remoteCommit, err := repo.CommitObject(remoteHash)
-	if err != nil {
-		return
+func (a *Analyzer) analyzeIdentity(repo *git.Repository, status *RepoStatus) {
+	if cfg, err := repo.Config(); err == nil {
+		seen := make(map[string]bool)
+		for _, remote := range cfg.Remotes {
+			for _, u := range remote.URLs {
+				n := NormalizeRemoteURL(u)
+				if n != "" && !seen[n] {
+					seen[n] = true
+					status.Remotes = append(status.Remotes, n)
+				}
+			}
+		}
+		sort.Strings(status.Remotes)
 	}
+}
 
-	mergeBase, err := localCommit.MergeBase(remoteCommit)
-	if err != nil || len(mergeBase) == 0 {
-		return
+// analyzeRootCommit walks the whole history on first sight of a repo, so it
+// is a probe of its own that can be skipped; the root cache keeps later scans
+// to the commits made since.
+func (a *Analyzer) analyzeRootCommit(ctx context.Context, repo *git.Repository, repoPath string, status *RepoStatus) {
+	if head, err := repo.Head(); err == nil {
+		status.RootCommit = a.roots.rootCommit(ctx, repoPath, commonGitDir(repoPath), head.Hash().String())
 	}
+}
 
-	base := mergeBase[0].Hash
-
-	status.UnpushedCommits = countCommitsBetween(repo, base, localHash)
-	status.UnpulledCommits = countCommitsBetween(repo, base, remoteHash)
+func localOnlyCommits(ctx context.Context, repoPath string) int {
+	out, err := gitCommand(ctx, "-C", repoPath, "rev-list", "--count", "--branches", "--not", "--remotes").Output()
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return n
 }
 
-func countCommitsBetween(repo *git.Repository, from, to plumbing.Hash) int {
+func countCommitsBetween(ctx context.Context, repo *git.Repository, from, to plumbing.Hash) int {
 	if from == to {
 		return 0
 	}
@@ -185,6 +357,9 @@ func countCommitsBetween(repo *git.Repository, from, to plumbing.Hash) int {
 	defer iter.Close()
 
 	iter.ForEach(func(c *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if c.Hash == from {
 			return errStop
 		}
@@ -201,18 +376,21 @@ type stopIter struct{}
 
 func (e *stopIter) Error() string { return "stop" }
 
-func (a *Analyzer) analyzeRecentCommits(repo *git.Repository, status *RepoStatus) {
+func (a *Analyzer) analyzeRecentCommits(ctx context.Context, repo *git.Repository, authors *authorMatcher, status *RepoStatus) {
 	iter, err := repo.Log(&git.LogOptions{})
 	if err != nil {
 		return
 	}
 	defer iter.Close()
 
-	for i := 0; i < 5; i++ {
+	for len(status.RecentCommits) < 5 && ctx.Err() == nil {
 		c, err := iter.Next()
 		if err != nil {
 			break
 		}
+		if !authors.match(c.Author) {
+			continue
+		}
 		status.RecentCommits = append(status.RecentCommits, Commit{
 			Hash:      c.Hash.String()[:7],
 			Author:    c.Author.Name,
@@ -222,60 +400,63 @@ func (a *Analyzer) analyzeRecentCommits(repo *git.Repository, status *RepoStatus
 	}
 }
 
-func (a *Analyzer) analyzeLinesChanged(repo *git.Repository, status *RepoStatus) {
-	since := time.Now().Add(-7 * 24 * time.Hour)
-	iter, err := repo.Log(&git.LogOptions{Since: &since})
+// analyzeDailyActivity counts commits per day over every local branch, and
+// HEAD when detached, so work on branches other than the checked-out one
+// shows up. The hashes are kept for tallyActivity, which counts a commit once
+// however many worktrees or clones reach it.
+func (a *Analyzer) analyzeDailyActivity(ctx context.Context, repo *git.Repository, repoPath string, authors *authorMatcher, status *RepoStatus) {
+	today := a.clock.today()
+	since := a.clock.start(today.AddDate(0, 0, -(a.activityDays - 1)))
+	commits, err := a.commitDays(ctx, repo, repoPath, authors, since)
 	if err != nil {
 		return
 	}
-	defer iter.Close()
-
-	var added, removed int
-	iter.ForEach(func(c *object.Commit) error {
-		stats, err := c.Stats()
-		if err != nil {
-			return nil
-		}
-		for _, s := range stats {
-			added += s.Addition
-			removed += s.Deletion
-		}
-		return nil
-	})
 
-	if added > 0 || removed > 0 {
-		status.LinesChanged = &LinesChanged{
-			Added:   added,
-			Removed: removed,
-			Period:  7 * 24 * time.Hour,
+	days := make([]int, a.activityDays)
+	indexes := make(map[string]int, len(commits))
+	for hash, day := range commits {
+		idx := daysBetween(day, today)
+		if idx >= 0 && idx < len(days) {
+			days[len(days)-1-idx]++
+			indexes[hash] = len(days) - 1 - idx
 		}
 	}
-}
 
-func (a *Analyzer) analyzeDailyActivity(repo *git.Repository, status *RepoStatus) {
-	now := time.Now()
-	since := now.AddDate(0, 0, -6)
-	since = time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location())
+	status.DailyActivity = days
+	status.activityCommits = indexes
+}
 
-	iter, err := repo.Log(&git.LogOptions{Since: &since})
+// commitDays maps each commit since the given time on any local branch or
+// HEAD, by one of authors, to the day it was authored on. Daily activity and
+// the heatmap both count these, so commits shared between repos can be
+// counted once across the tree.
+func (a *Analyzer) commitDays(ctx context.Context, repo *git.Repository, repoPath string, authors *authorMatcher, since time.Time) (map[string]time.Time, error) {
+	args := []string{"-C", repoPath, "log", "--branches", "--format=%H%x00%an%x00%ae%x00%at",
+		"--since=" + since.Format(time.RFC3339)}
+	if _, err := repo.Head(); err == nil {
+		args = append(args, "HEAD")
+	}
+	out, err := gitCommand(ctx, args...).Output()
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer iter.Close()
-
-	days := make([]int, 7)
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	iter.ForEach(func(c *object.Commit) error {
-		d := today.Sub(time.Date(c.Author.When.Year(), c.Author.When.Month(), c.Author.When.Day(), 0, 0, 0, 0, c.Author.When.Location()))
-		idx := int(d.Hours() / 24)
-		if idx >= 0 && idx < 7 {
-			days[6-idx]++
+	commits := make(map[string]time.Time)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
 		}
-		return nil
-	})
-
-	status.DailyActivity = days
+		if !authors.match(object.Signature{Name: fields[1], Email: fields[2]}) {
+			continue
+		}
+		at, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		commits[fields[0]] = a.clock.day(time.Unix(at, 0))
+	}
+	return commits, nil
 }
 
 func firstLine(s string) string {
@@ -289,6 +470,9 @@ func firstLine(s string) string {
 
 func SortByLastActive(repos []RepoStatus) {
 	sort.Slice(repos, func(i, j int) bool {
-		return repos[i].LastCommitTime.After(repos[j].LastCommitTime)
+		if !repos[i].LastCommitTime.Equal(repos[j].LastCommitTime) {
+			return repos[i].LastCommitTime.After(repos[j].LastCommitTime)
+		}
+		return repos[i].Path < repos[j].Path
 	})
 }
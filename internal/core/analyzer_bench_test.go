@@ -26,7 +26,7 @@ func BenchmarkAnalyze(b *testing.B) {
 	ghostThreshold := DefaultGhostThreshold
 
 	b.Run("Full", func(b *testing.B) {
-		a := NewAnalyzer(false, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{GhostThreshold: ghostThreshold})
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			a.Analyze(context.Background(), repoPath)
@@ -34,7 +34,7 @@ func BenchmarkAnalyze(b *testing.B) {
 	})
 
 	b.Run("FullDetail", func(b *testing.B) {
-		a := NewAnalyzer(true, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{DetailMode: true, GhostThreshold: ghostThreshold})
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			a.Analyze(context.Background(), repoPath)
@@ -50,7 +50,7 @@ func BenchmarkAnalyze(b *testing.B) {
 
 	b.Run("Branch", func(b *testing.B) {
 		repo, _ := git.PlainOpen(repoPath)
-		a := NewAnalyzer(false, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{GhostThreshold: ghostThreshold})
 		status := &RepoStatus{}
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
@@ -59,17 +59,17 @@ func BenchmarkAnalyze(b *testing.B) {
 	})
 
 	b.Run("WorktreeStatus", func(b *testing.B) {
-		a := NewAnalyzer(false, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{GhostThreshold: ghostThreshold})
 		status := &RepoStatus{}
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			a.analyzeWorktree(repoPath, status)
+			a.analyzeWorktree(context.Background(), repoPath, status)
 		}
 	})
 
 	b.Run("LastCommit", func(b *testing.B) {
 		repo, _ := git.PlainOpen(repoPath)
-		a := NewAnalyzer(false, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{GhostThreshold: ghostThreshold})
 		status := &RepoStatus{}
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
@@ -79,33 +79,32 @@ func BenchmarkAnalyze(b *testing.B) {
 
 	b.Run("RemoteStatus", func(b *testing.B) {
 		repo, _ := git.PlainOpen(repoPath)
-		a := NewAnalyzer(false, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{GhostThreshold: ghostThreshold})
 		status := &RepoStatus{}
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			a.analyzeRemoteStatus(repo, status)
+			a.analyzeRemoteStatus(context.Background(), repo, repoPath, status)
 		}
 	})
 
 	b.Run("RecentCommits", func(b *testing.B) {
 		repo, _ := git.PlainOpen(repoPath)
-		a := NewAnalyzer(true, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{DetailMode: true, GhostThreshold: ghostThreshold})
 		status := &RepoStatus{}
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			status.RecentCommits = nil
-			a.analyzeRecentCommits(repo, status)
+			a.analyzeRecentCommits(context.Background(), repo, nil, status)
 		}
 	})
 
 	b.Run("LinesChanged", func(b *testing.B) {
-		repo, _ := git.PlainOpen(repoPath)
-		a := NewAnalyzer(true, false, ghostThreshold)
+		a := NewAnalyzer(ScanConfig{DetailMode: true, GhostThreshold: ghostThreshold})
 		status := &RepoStatus{}
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			status.LinesChanged = nil
-			a.analyzeLinesChanged(repo, status)
+			a.analyzeLinesChanged(context.Background(), repoPath, nil, status)
 		}
 	})
 }
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// authorMatcher decides which commits count as the user's own. A nil matcher
+// matches every commit.
+type authorMatcher struct {
+	emails map[string]bool
+	names  map[string]bool
+}
+
+var errNoIdentity = errors.New("no git identity (user.name and user.email) to count commits as yours")
+
+// authorsFor resolves the filter for one repo. Mine uses the identity git
+// would commit with there, so includeIf and per-repo overrides apply; if git
+// has none, the matcher holds only the aliases and errNoIdentity says why.
+func (f AuthorFilter) authorsFor(ctx context.Context, repoPath string) (*authorMatcher, error) {
+	if !f.Active() {
+		return nil, nil
+	}
+	m := &authorMatcher{emails: make(map[string]bool), names: make(map[string]bool)}
+	for _, alias := range f.Aliases {
+		m.add(alias)
+	}
+	if f.Mine {
+		name, email, ok := gitIdentity(ctx, repoPath)
+		if !ok {
+			return m, errNoIdentity
+		}
+		m.add(name)
+		m.add(email)
+	}
+	return m, nil
+}
+
+func (m *authorMatcher) add(alias string) {
+	alias = strings.TrimSpace(alias)
+	switch {
+	case alias == "":
+	case strings.Contains(alias, "@"):
+		m.emails[strings.ToLower(alias)] = true
+	default:
+		m.names[strings.ToLower(alias)] = true
+	}
+}
+
+func (m *authorMatcher) match(sig object.Signature) bool {
+	if m == nil {
+		return true
+	}
+	return m.emails[strings.ToLower(sig.Email)] || m.names[strings.ToLower(sig.Name)]
+}
+
+// identities lists what the matcher accepts, for reporting.
+func (m *authorMatcher) identities() []string {
+	if m == nil {
+		return nil
+	}
+	var ids []string
+	for name := range m.names {
+		ids = append(ids, name)
+	}
+	for email := range m.emails {
+		ids = append(ids, email)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// gitIdentity reads the author ident git would use in repoPath, e.g.
+// "Jane Doe <jane@example.com> 1700000000 +0100".
+func gitIdentity(ctx context.Context, repoPath string) (string, string, bool) {
+	out, err := gitCommand(ctx, "-C", repoPath, "var", "GIT_AUTHOR_IDENT").Output()
+	if err != nil {
+		return "", "", false
+	}
+	ident := strings.TrimSpace(string(out))
+	start := strings.Index(ident, "<")
+	end := strings.Index(ident, ">")
+	if start < 0 || end < start {
+		return "", "", false
+	}
+	return strings.TrimSpace(ident[:start]), ident[start+1 : end], true
+}
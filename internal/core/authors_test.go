@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestAuthorFilter(t *testing.T) {
+	requireGit(t)
+	repoPath := t.TempDir()
+	runGit(t, repoPath, "init", "-q")
+	runGit(t, repoPath, "config", "user.name", "Jane Doe")
+	runGit(t, repoPath, "config", "user.email", "jane@work.example")
+
+	if m, err := (AuthorFilter{}).authorsFor(context.Background(), repoPath); m != nil || err != nil {
+		t.Fatal("inactive filter should match everything")
+	}
+
+	m, err := AuthorFilter{Mine: true, Aliases: []string{"jane@home.example", "jd"}}.authorsFor(context.Background(), repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sig  object.Signature
+		want bool
+	}{
+		{object.Signature{Name: "Someone", Email: "JANE@work.example"}, true},
+		{object.Signature{Name: "Jane Doe", Email: "jane@laptop.local"}, true},
+		{object.Signature{Name: "x", Email: "jane@home.example"}, true},
+		{object.Signature{Name: "JD", Email: "ci@example.com"}, true},
+		{object.Signature{Name: "Bob", Email: "bob@work.example"}, false},
+	}
+	for _, tt := range tests {
+		if got := m.match(tt.sig); got != tt.want {
+			t.Errorf("match(%s <%s>) = %v, want %v", tt.sig.Name, tt.sig.Email, got, tt.want)
+		}
+	}
+
+	// Without an identity, --mine keeps the aliases and says why nothing else
+	// matches, rather than silently dropping every commit.
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	anonymous := t.TempDir()
+	runGit(t, anonymous, "init", "-q")
+	runGit(t, anonymous, "config", "user.useConfigOnly", "true")
+	m, err = AuthorFilter{Mine: true, Aliases: []string{"jd"}}.authorsFor(context.Background(), anonymous)
+	if !errors.Is(err, errNoIdentity) || !m.match(object.Signature{Name: "jd"}) {
+		t.Errorf("no identity: err = %v, identities = %v", err, m.identities())
+	}
+
+	result, err := NewScanner(ScanConfig{
+		RootPath:   anonymous,
+		IgnoreFile: filepath.Join(anonymous, "none"),
+		Authors:    AuthorFilter{Mine: true},
+	}).Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Kind != ErrorAuthors || result.Errors[0].Path != anonymous {
+		t.Errorf("errors = %+v, want the missing identity reported", result.Errors)
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// defaultBranch prefers the remote's HEAD, which is what pull requests merge
+// into, then falls back to a local or remote main or master.
+func defaultBranch(repo *git.Repository) (plumbing.ReferenceName, bool) {
+	for _, remote := range []string{"origin", "upstream"} {
+		ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName(remote), false)
+		if err == nil && ref.Type() == plumbing.SymbolicReference {
+			if _, err := repo.Reference(ref.Target(), true); err == nil {
+				return ref.Target(), true
+			}
+		}
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName("main"),
+		plumbing.NewBranchReferenceName("master"),
+		plumbing.NewRemoteReferenceName("origin", "main"),
+		plumbing.NewRemoteReferenceName("origin", "master"),
+	} {
+		if _, err := repo.Reference(name, true); err == nil {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (a *Analyzer) analyzeBranches(ctx context.Context, repo *git.Repository, repoPath string, status *RepoStatus) {
+	defaultRef, hasDefault := defaultBranch(repo)
+	if hasDefault {
+		status.DefaultBranch = defaultRef.Short()
+	}
+
+	iter, err := repo.Branches()
+	if err != nil {
+		return
+	}
+	var refs []*plumbing.Reference
+	iter.ForEach(func(ref *plumbing.Reference) error {
+		refs = append(refs, ref)
+		return nil
+	})
+
+	tracking := trackingRefs(ctx, repoPath)
+	head, _ := repo.Head()
+
+	for _, ref := range refs {
+		branch := BranchStatus{
+			Name:    ref.Name().Short(),
+			Current: head != nil && head.Name() == ref.Name(),
+		}
+		if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+			branch.LastCommitTime = commit.Author.When
+		}
+
+		t := tracking[ref.Name().String()]
+		branch.Upstream = compareRef(ctx, repo, ref.Hash(), t.upstream)
+		branch.Push = compareRef(ctx, repo, ref.Hash(), t.pushRef(repo, ref.Name()))
+		if hasDefault && ref.Name() != defaultRef && !isLocalBranchOf(ref.Name(), defaultRef) {
+			branch.Default = compareRef(ctx, repo, ref.Hash(), defaultRef.String())
+			branch.Merged = branch.Default != nil && !branch.Default.Gone && branch.Default.Ahead == 0
+		}
+
+		switch {
+		case branch.Push != nil && !branch.Push.Gone:
+			branch.Unpushed = branch.Push.Ahead
+		case branch.Upstream != nil && !branch.Upstream.Gone:
+			branch.Unpushed = branch.Upstream.Ahead
+		case branch.Merged:
+			// Its commits are safe on the default branch, pushed or not.
+		default:
+			branch.NeverPushed = true
+			if branch.Default != nil {
+				branch.Unpushed = branch.Default.Ahead
+			}
+		}
+
+		status.Branches = append(status.Branches, branch)
+	}
+
+	sort.Slice(status.Branches, func(i, j int) bool {
+		return status.Branches[i].LastCommitTime.After(status.Branches[j].LastCommitTime)
+	})
+}
+
+// isLocalBranchOf reports whether a local branch is the local copy of the
+// remote default branch, e.g. main for origin/main.
+func isLocalBranchOf(local, defaultRef plumbing.ReferenceName) bool {
+	if !defaultRef.IsRemote() {
+		return false
+	}
+	_, branch, ok := strings.Cut(defaultRef.Short(), "/")
+	return ok && local.Short() == branch
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeBranches(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	clone := filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "-q", "--bare", "-b", "main", origin)
+	runGit(t, root, "clone", "-q", origin, clone)
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, clone, "push", "-q", "-u", "origin", "main")
+	runGit(t, clone, "remote", "set-head", "origin", "main")
+
+	runGit(t, clone, "checkout", "-q", "-b", "merged")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "merged work")
+	runGit(t, clone, "checkout", "-q", "main")
+	runGit(t, clone, "merge", "-q", "--ff-only", "merged")
+	runGit(t, clone, "push", "-q", "origin", "main")
+
+	runGit(t, clone, "checkout", "-q", "-b", "pushed")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "pushed work")
+	runGit(t, clone, "push", "-q", "-u", "origin", "pushed")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "unpushed work")
+
+	runGit(t, clone, "checkout", "-q", "-b", "local", "main")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "local one")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "local two")
+
+	repo, err := openRepo(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := &RepoStatus{}
+	NewAnalyzer(ScanConfig{Branches: true}).analyzeBranches(context.Background(), repo, clone, status)
+
+	if status.DefaultBranch != "origin/main" {
+		t.Errorf("default branch = %q, want origin/main", status.DefaultBranch)
+	}
+
+	want := map[string]struct {
+		merged      bool
+		unpushed    int
+		neverPushed bool
+		current     bool
+	}{
+		"main":   {false, 0, false, false},
+		"merged": {true, 0, false, false},
+		"pushed": {false, 1, false, false},
+		"local":  {false, 2, true, true},
+	}
+	if len(status.Branches) != len(want) {
+		t.Fatalf("got %d branches, want %d", len(status.Branches), len(want))
+	}
+	for _, b := range status.Branches {
+		w := want[b.Name]
+		if b.Merged != w.merged || b.Unpushed != w.unpushed || b.NeverPushed != w.neverPushed || b.Current != w.current {
+			t.Errorf("%s: merged=%v unpushed=%d never=%v current=%v, want %+v", b.Name, b.Merged, b.Unpushed, b.NeverPushed, b.Current, w)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/karrick/godirwalk"
+)
+
+const cacheVersion = 3
+
+func DefaultCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pulse", "discovery.json")
+}
+
+type cacheFile struct {
+	Version int                     `json:"version"`
+	Dirs    map[string]cachedDir    `json:"dirs"`
+	NonGit  map[string]cachedNonGit `json:"non_git,omitempty"`
+}
+
+// cachedNonGit is a non-git directory's classification along with the mtime
+// of every directory the classifying walk read. It is reused while none of
+// those mtimes change, that is until a file is added, removed or renamed
+// somewhere inside; edits in place are picked up by --rescan.
+type cachedNonGit struct {
+	Dirs map[string]int64 `json:"dirs"`
+	Info NonGitDir        `json:"info"`
+}
+
+// cachedDir is what a single readdir told us about a directory. It stays valid
+// for as long as the directory's mtime is unchanged, because adding, removing
+// or renaming an entry always bumps the mtime of its parent. Whether a .git
+// file points anywhere or a HEAD belongs to a bare repo depends on file
+// contents the mtime doesn't cover, so those are checked again on every
+// lookup, and only for directories that have such a file.
+type cachedDir struct {
+	ModTime    int64    `json:"mtime"`
+	Dirs       []string `json:"dirs,omitempty"`
+	Links      []string `json:"links,omitempty"`
+	HasGitFile bool     `json:"has_git_file,omitempty"`
+	HasHead    bool     `json:"has_head,omitempty"`
+
+	GitFile bool `json:"-"`
+	Bare    bool `json:"-"`
+}
+
+type cacheStats struct {
+	hits   int
+	misses int
+}
+
+type discoveryCache struct {
+	path   string
+	rescan bool
+
+	mu            sync.Mutex
+	data          cacheFile
+	visited       map[string]bool
+	visitedNonGit map[string]bool
+	counts        cacheStats
+}
+
+func loadDiscoveryCache(path string, rescan bool) *discoveryCache {
+	c := &discoveryCache{
+		path:          path,
+		rescan:        rescan,
+		visited:       make(map[string]bool),
+		visitedNonGit: make(map[string]bool),
+	}
+
+	if path != "" {
+		if raw, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(raw, &c.data)
+		}
+	}
+	if c.data.Version != cacheVersion || c.data.Dirs == nil {
+		c.data = cacheFile{Version: cacheVersion, Dirs: make(map[string]cachedDir)}
+	}
+	if c.data.NonGit == nil {
+		c.data.NonGit = make(map[string]cachedNonGit)
+	}
+	return c
+}
+
+// lookup returns what is known about dir, re-reading it only when its mtime
+// no longer matches the cached entry or a full rescan was requested.
+func (c *discoveryCache) lookup(dir string, info os.FileInfo) (cachedDir, error) {
+	mtime := info.ModTime().UnixNano()
+
+	c.mu.Lock()
+	entry, ok := c.data.Dirs[dir]
+	c.visited[dir] = true
+	if ok && !c.rescan && entry.ModTime == mtime {
+		c.counts.hits++
+		c.mu.Unlock()
+		entry.validate(dir)
+		return entry, nil
+	}
+	c.counts.misses++
+	c.mu.Unlock()
+
+	entry, err := readCachedDir(dir)
+	if err != nil {
+		return cachedDir{}, err
+	}
+	entry.ModTime = mtime
+
+	c.mu.Lock()
+	c.data.Dirs[dir] = entry
+	c.mu.Unlock()
+	entry.validate(dir)
+	return entry, nil
+}
+
+func (e *cachedDir) validate(dir string) {
+	if e.HasGitFile {
+		_, e.GitFile = readGitFile(filepath.Join(dir, ".git"))
+	}
+	if e.HasHead {
+		e.Bare = isBareRepo(dir)
+	}
+}
+
+func (c *discoveryCache) subdirs(dir string) []string {
+	c.mu.Lock()
+	entry, ok := c.data.Dirs[dir]
+	c.mu.Unlock()
+	if ok {
+		return entry.Dirs
+	}
+
+	entry, err := readCachedDir(dir)
+	if err != nil {
+		return nil
+	}
+	return entry.Dirs
+}
+
+func readCachedDir(dir string) (cachedDir, error) {
+	dirents, err := godirwalk.ReadDirents(dir, nil)
+	if err != nil {
+		return cachedDir{}, err
+	}
+
+	var entry cachedDir
+	for _, de := range dirents {
+		switch {
+		case de.IsDir():
+			entry.Dirs = append(entry.Dirs, de.Name())
+		case de.IsSymlink():
+			entry.Links = append(entry.Links, de.Name())
+		case de.IsRegular() && de.Name() == ".git":
+			entry.HasGitFile = true
+		case de.IsRegular() && de.Name() == "HEAD":
+			entry.HasHead = true
+		}
+	}
+	return entry, nil
+}
+
+// classify returns the classification of the non-git directory dir, walking
+// it again only if a directory the last walk read has changed since.
+func (c *discoveryCache) classify(dir string, ignore *ignoreMatcher) NonGitDir {
+	c.mu.Lock()
+	entry, ok := c.data.NonGit[dir]
+	c.visitedNonGit[dir] = true
+	c.mu.Unlock()
+	if ok && !c.rescan && unchanged(entry.Dirs) {
+		return entry.Info
+	}
+
+	info, dirs := classifyNonGit(dir, ignore)
+	c.mu.Lock()
+	c.data.NonGit[dir] = cachedNonGit{Dirs: dirs, Info: info}
+	c.mu.Unlock()
+	return info
+}
+
+func unchanged(dirs map[string]int64) bool {
+	if len(dirs) == 0 {
+		return false
+	}
+	for dir, mtime := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || info.ModTime().UnixNano() != mtime {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *discoveryCache) stats() cacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts
+}
+
+// save drops entries under the scanned roots that were not visited this run,
+// so deleted directories do not accumulate, and keeps everything else.
+func (c *discoveryCache) save(roots []ScanRoot) error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	absRoots := make([]string, len(roots))
+	for i, root := range roots {
+		absRoots[i] = absPath(root.Path)
+	}
+	underRoots := func(dir string) bool {
+		for _, root := range absRoots {
+			if dir == root || isWithin(dir, root) {
+				return true
+			}
+		}
+		return false
+	}
+	for dir := range c.data.Dirs {
+		if !c.visited[dir] && underRoots(dir) {
+			delete(c.data.Dirs, dir)
+		}
+	}
+	for dir := range c.data.NonGit {
+		if !c.visitedNonGit[dir] && underRoots(dir) {
+			delete(c.data.NonGit, dir)
+		}
+	}
+
+	raw, err := json.Marshal(c.data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
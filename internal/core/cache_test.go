@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoveryCacheIncremental(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/.git", "b/c/.git", "d"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewScanner(ScanConfig{
+		RootPath:   root,
+		IgnoreFile: filepath.Join(root, "none"),
+		CacheFile:  filepath.Join(t.TempDir(), "discovery.json"),
+	})
+
+	first, err := s.findRepos(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.cache.hits != 0 || len(first.repos) != 2 {
+		t.Fatalf("cold walk: %d hits, %d repos; want 0 hits, 2 repos", first.cache.hits, len(first.repos))
+	}
+
+	warm, _ := s.findRepos(context.Background(), nil)
+	if warm.cache.misses != 0 || len(warm.repos) != 2 {
+		t.Fatalf("warm walk: %d misses, %d repos; want 0 misses, 2 repos", warm.cache.misses, len(warm.repos))
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "d", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	changed, _ := s.findRepos(context.Background(), nil)
+	if changed.cache.misses != 1 || len(changed.repos) != 3 {
+		t.Fatalf("after git init in d: %d misses, %d repos; want 1 miss, 3 repos", changed.cache.misses, len(changed.repos))
+	}
+
+	s.config.Rescan = true
+	rescan, _ := s.findRepos(context.Background(), nil)
+	if rescan.cache.hits != 0 || len(rescan.repos) != 3 {
+		t.Fatalf("rescan: %d hits, %d repos; want 0 hits, 3 repos", rescan.cache.hits, len(rescan.repos))
+	}
+}
+
+func TestDiscoveryCacheRevalidatesGitFiles(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "store", "wt")
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	linked := filepath.Join(root, "linked")
+	if err := os.MkdirAll(linked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitFile := filepath.Join(linked, ".git")
+	if err := os.WriteFile(gitFile, []byte("gitdir: "+target+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScanner(ScanConfig{
+		RootPath:   root,
+		IgnoreFile: filepath.Join(root, "none"),
+		CacheFile:  filepath.Join(t.TempDir(), "discovery.json"),
+	})
+	if first, _ := s.findRepos(context.Background(), nil); len(first.repos) != 1 {
+		t.Fatalf("cold walk: %v, want the linked worktree", first.repos)
+	}
+
+	// Rewriting the file in place leaves the directory's mtime alone.
+	if err := os.WriteFile(gitFile, []byte("not a gitdir line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warm, _ := s.findRepos(context.Background(), nil)
+	if warm.cache.misses != 0 || len(warm.repos) != 0 {
+		t.Errorf("warm walk: %d misses, repos %v; want a cache hit and no repos", warm.cache.misses, warm.repos)
+	}
+}
+
+func TestDiscoveryCacheSaveError(t *testing.T) {
+	root := t.TempDir()
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScanner(ScanConfig{
+		RootPath:   root,
+		IgnoreFile: filepath.Join(root, "none"),
+		CacheFile:  filepath.Join(blocker, "discovery.json"),
+	})
+	found, err := s.findRepos(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found.errors) != 1 || found.errors[0].Kind != ErrorCache {
+		t.Errorf("errors = %+v, want one cache error", found.errors)
+	}
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// dayClock decides which calendar day a commit belongs to. Every bucket uses
+// the same zone regardless of the offset a commit was recorded with, and a day
+// runs from startHour to startHour the next morning, so a commit at 1am with
+// startHour 4 still counts towards the evening before.
+type dayClock struct {
+	loc       *time.Location
+	startHour int
+}
+
+func newDayClock(config ScanConfig) dayClock {
+	loc := config.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return dayClock{loc: loc, startHour: config.DayStartHour}
+}
+
+// day returns the midnight, in the clock's zone, of the day t falls in.
+func (c dayClock) day(t time.Time) time.Time {
+	t = t.In(c.loc).Add(-time.Duration(c.startHour) * time.Hour)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+}
+
+func (c dayClock) today() time.Time {
+	return c.day(time.Now())
+}
+
+// start is the instant day begins, for bounding log walks.
+func (c dayClock) start(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.startHour, 0, 0, 0, c.loc)
+}
+
+func (c dayClock) date(t time.Time) string {
+	return c.day(t).Format(dateLayout)
+}
+
+// daysBetween counts calendar days from a to b. Comparing dates rather than
+// durations keeps DST changes from shifting the count.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+// zoneName reports the clock's zone by its IANA name where one can be found.
+// time.Local only knows itself as "Local", so fall back to TZ and the
+// /etc/localtime link before giving up.
+func (c dayClock) zoneName() string {
+	if c.loc != time.Local {
+		return c.loc.String()
+	}
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		return tz
+	}
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return c.loc.String()
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDayClockBucketsInOneZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	tokyo := time.FixedZone("JST", 9*3600)
+	la := time.FixedZone("PDT", -7*3600)
+
+	tests := []struct {
+		name      string
+		startHour int
+		when      time.Time
+		want      string
+	}{
+		{"late evening in LA is next morning in Berlin", 0, time.Date(2026, 6, 1, 22, 30, 0, 0, la), "2026-06-02"},
+		{"early morning in Tokyo is previous evening in Berlin", 0, time.Date(2026, 6, 2, 3, 0, 0, 0, tokyo), "2026-06-01"},
+		{"night owl commit counts towards the day before", 4, time.Date(2026, 6, 2, 2, 59, 0, 0, berlin), "2026-06-01"},
+		{"day start boundary belongs to the new day", 4, time.Date(2026, 6, 2, 4, 0, 0, 0, berlin), "2026-06-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newDayClock(ScanConfig{Location: berlin, DayStartHour: tt.startHour})
+			if got := clock.date(tt.when); got != tt.want {
+				t.Errorf("date(%s) = %s, want %s", tt.when, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysBetweenAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	before := time.Date(2026, 3, 28, 0, 0, 0, 0, berlin)
+	after := time.Date(2026, 3, 30, 0, 0, 0, 0, berlin)
+	if got := daysBetween(before, after); got != 2 {
+		t.Errorf("daysBetween across spring forward = %d, want 2", got)
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// analyzeDetached gives a detached HEAD the context `git describe` would: the
+// nearest tag and how far past it HEAD is, the refs that already contain the
+// commit, and how many commits only HEAD reaches.
+func (a *Analyzer) analyzeDetached(ctx context.Context, repo *git.Repository, repoPath string, status *RepoStatus) {
+	head, err := repo.Head()
+	if err != nil || head.Name().IsBranch() {
+		return
+	}
+	detached := &DetachedHead{Commit: shortHash(head.Hash().String())}
+
+	if out, err := gitCommand(ctx, "-C", repoPath, "describe", "--tags", "--long", "HEAD").Output(); err == nil {
+		detached.Tag, detached.Distance = parseDescribe(strings.TrimSpace(string(out)))
+	}
+
+	if out, err := gitCommand(ctx, "-C", repoPath, "for-each-ref", "--contains", "HEAD",
+		"--format=%(refname)", "refs/heads", "refs/remotes").Output(); err == nil {
+		for _, ref := range strings.Fields(string(out)) {
+			if strings.HasSuffix(ref, "/HEAD") {
+				continue
+			}
+			detached.ContainedIn = append(detached.ContainedIn, shortRefName(ref))
+		}
+	}
+
+	if out, err := gitCommand(ctx, "-C", repoPath, "rev-list", "--count", "HEAD",
+		"--not", "--branches", "--remotes", "--tags").Output(); err == nil {
+		detached.Unreachable, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+	}
+
+	status.Detached = detached
+}
+
+// parseDescribe splits `git describe --long` output such as
+// v1.2.0-3-gabc1234 into the tag and the distance. Tags may contain dashes.
+func parseDescribe(out string) (string, int) {
+	i := strings.LastIndex(out, "-g")
+	if i < 0 {
+		return "", 0
+	}
+	out = out[:i]
+	j := strings.LastIndex(out, "-")
+	if j < 0 {
+		return "", 0
+	}
+	distance, err := strconv.Atoi(out[j+1:])
+	if err != nil {
+		return "", 0
+	}
+	return out[:j], distance
+}
+
+func shortRefName(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/remotes/", "refs/tags/"} {
+		if rest, ok := strings.CutPrefix(ref, prefix); ok {
+			return rest
+		}
+	}
+	return ref
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseDescribe(t *testing.T) {
+	tests := []struct {
+		in       string
+		tag      string
+		distance int
+	}{
+		{"v1.2.0-3-gabc1234", "v1.2.0", 3},
+		{"release-2024-01-0-g0123abc", "release-2024-01", 0},
+		{"abc1234", "", 0},
+	}
+	for _, tt := range tests {
+		tag, distance := parseDescribe(tt.in)
+		if tag != tt.tag || distance != tt.distance {
+			t.Errorf("parseDescribe(%q) = %q, %d, want %q, %d", tt.in, tag, distance, tt.tag, tt.distance)
+		}
+	}
+}
+
+func TestAnalyzeDetached(t *testing.T) {
+	requireGit(t)
+	repoPath := t.TempDir()
+	runGit(t, repoPath, "init", "-q", "-b", "main")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "one")
+	runGit(t, repoPath, "tag", "v1.0.0")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "two")
+	runGit(t, repoPath, "checkout", "-q", "--detach", "v1.0.0")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "detached work")
+
+	repo, err := openRepo(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := &RepoStatus{}
+	NewAnalyzer(ScanConfig{}).analyzeDetached(context.Background(), repo, repoPath, status)
+
+	d := status.Detached
+	if d == nil {
+		t.Fatal("detached HEAD not reported")
+	}
+	if d.Tag != "v1.0.0" || d.Distance != 1 || d.Unreachable != 1 || len(d.ContainedIn) != 0 {
+		t.Errorf("detached = %+v", d)
+	}
+	if !atRisk(status) {
+		t.Error("unreachable detached commits not flagged at risk")
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NormalizeRemoteURL reduces the ssh, https, git and scp-style spellings of a
+// remote to host/path, so `git@github.com:org/api.git` and
+// `https://github.com/org/api` compare equal. Hosts and paths are lowercased
+// because the common forges treat them case-insensitively.
+func NormalizeRemoteURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	var host, path string
+	switch {
+	case strings.Contains(raw, "://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return strings.ToLower(strings.TrimSuffix(raw, ".git"))
+		}
+		if u.Scheme == "file" {
+			return filepath.Clean(strings.TrimSuffix(u.Path, ".git"))
+		}
+		host, path = u.Hostname(), u.Path
+	case isScpLike(raw):
+		hostPart, p, _ := strings.Cut(raw, ":")
+		if i := strings.LastIndex(hostPart, "@"); i >= 0 {
+			hostPart = hostPart[i+1:]
+		}
+		host, path = hostPart, p
+	default:
+		return filepath.Clean(strings.TrimSuffix(strings.TrimSuffix(raw, "/"), ".git"))
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimPrefix(path, "~")
+	path = strings.TrimSuffix(path, ".git")
+	path = strings.Trim(path, "/")
+	return strings.ToLower(host + "/" + path)
+}
+
+func isScpLike(raw string) bool {
+	colon := strings.Index(raw, ":")
+	if colon <= 0 {
+		return false
+	}
+	slash := strings.Index(raw, "/")
+	return slash < 0 || colon < slash
+}
+
+// findDuplicates groups clones that share a normalized remote or a root
+// commit. Linked worktrees are skipped since they share a repository rather
+// than being separate clones of it.
+func findDuplicates(repos []RepoStatus) []DuplicateGroup {
+	parent := make([]int, len(repos))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		parent[find(a)] = find(b)
+	}
+
+	byKey := make(map[string]int)
+	link := func(key string, i int) {
+		if j, ok := byKey[key]; ok {
+			union(i, j)
+		} else {
+			byKey[key] = i
+		}
+	}
+	for i, repo := range repos {
+		if repo.Worktree == WorktreeLinked {
+			continue
+		}
+		for _, remote := range repo.Remotes {
+			link("remote "+remote, i)
+		}
+		if repo.RootCommit != "" {
+			link("root "+repo.RootCommit, i)
+		}
+	}
+
+	members := make(map[int][]int)
+	for i, repo := range repos {
+		if repo.Worktree == WorktreeLinked {
+			continue
+		}
+		members[find(i)] = append(members[find(i)], i)
+	}
+
+	var groups []DuplicateGroup
+	for _, idx := range members {
+		if len(idx) < 2 {
+			continue
+		}
+		groups = append(groups, newDuplicateGroup(repos, idx))
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+func newDuplicateGroup(repos []RepoStatus, idx []int) DuplicateGroup {
+	remoteCount := make(map[string]int)
+	newest := idx[0]
+	for _, i := range idx {
+		for _, remote := range repos[i].Remotes {
+			remoteCount[remote]++
+		}
+		if repos[i].LastCommitTime.After(repos[newest].LastCommitTime) {
+			newest = i
+		}
+	}
+
+	group := DuplicateGroup{}
+	best := 1
+	for remote, n := range remoteCount {
+		if n > best || (n == best && group.Key != "" && remote < group.Key) {
+			group.Key, best = remote, n
+		}
+	}
+	if group.Key == "" {
+		group.Key = "root " + shortHash(repos[idx[0]].RootCommit)
+	}
+
+	for _, i := range idx {
+		group.Clones = append(group.Clones, DuplicateClone{
+			Name:           repos[i].Name,
+			Path:           repos[i].Path,
+			Kind:           repos[i].Kind,
+			LastCommitTime: repos[i].LastCommitTime,
+			Newest:         i == newest,
+			NoRemote:       len(repos[i].Remotes) == 0,
+		})
+	}
+	sort.Slice(group.Clones, func(a, b int) bool {
+		return group.Clones[a].LastCommitTime.After(group.Clones[b].LastCommitTime)
+	})
+	return group
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,49 @@
+package core
+
+import "testing"
+
+func TestNormalizeRemoteURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"git@github.com:Org/API.git", "github.com/org/api"},
+		{"https://github.com/org/api", "github.com/org/api"},
+		{"https://user@github.com/org/api.git/", "github.com/org/api"},
+		{"ssh://git@github.com:22/org/api.git", "github.com/org/api"},
+		{"git://github.com/org/api.git", "github.com/org/api"},
+		{"ssh://git@host.example/~user/api.git", "host.example/user/api"},
+		{"/srv/git/api.git", "/srv/git/api"},
+		{"file:///srv/git/api.git", "/srv/git/api"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeRemoteURL(tt.in); got != tt.want {
+			t.Errorf("NormalizeRemoteURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	repos := []RepoStatus{
+		{Name: "api", Remotes: []string{"github.com/org/api"}, RootCommit: "aaa"},
+		{Name: "api-old", Remotes: []string{"github.com/org/api"}, RootCommit: "aaa"},
+		{Name: "api-experiment", RootCommit: "aaa"},
+		{Name: "api-wt", Worktree: WorktreeLinked, Remotes: []string{"github.com/org/api"}, RootCommit: "aaa"},
+		{Name: "web", Remotes: []string{"github.com/org/web"}, RootCommit: "bbb"},
+	}
+
+	groups := findDuplicates(repos)
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	if groups[0].Key != "github.com/org/api" || len(groups[0].Clones) != 3 {
+		t.Errorf("got key %q with %d clones, want github.com/org/api with 3", groups[0].Key, len(groups[0].Clones))
+	}
+	for _, clone := range groups[0].Clones {
+		if clone.NoRemote != (clone.Name == "api-experiment") {
+			t.Errorf("%s: no remote = %v", clone.Name, clone.NoRemote)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guidefari/pulse/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fetchStage runs `git fetch` between discovery and analysis with its own
+// concurrency limit, so slow remotes don't hold analysis workers. A repo is
+// passed on to analysis once its fetch has finished, one way or another.
+// Worktrees of one repository share refs and objects, so the repository is
+// fetched once and every worktree reports that fetch's outcome.
+type fetchStage struct {
+	timeout time.Duration
+	workers int
+
+	mu      sync.Mutex
+	results map[string]*FetchResult
+	shared  map[string]*sharedFetch
+}
+
+type sharedFetch struct {
+	done   chan struct{}
+	result *FetchResult
+}
+
+func newFetchStage(config ScanConfig) *fetchStage {
+	timeout, workers := config.FetchTimeout, config.FetchWorkers
+	if timeout <= 0 {
+		timeout = DefaultFetchTimeout
+	}
+	if workers <= 0 {
+		workers = DefaultFetchWorkers
+	}
+	return &fetchStage{
+		timeout: timeout,
+		workers: workers,
+		results: make(map[string]*FetchResult),
+		shared:  make(map[string]*sharedFetch),
+	}
+}
+
+func (f *fetchStage) stream(ctx context.Context, paths <-chan string) <-chan string {
+	fetched := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < f.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				result := f.fetch(ctx, path)
+				f.mu.Lock()
+				f.results[path] = result
+				f.mu.Unlock()
+				fetched <- path
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(fetched)
+	}()
+	return fetched
+}
+
+// fetch runs the fetch for path's repository, or waits for the one another
+// of its worktrees already started.
+func (f *fetchStage) fetch(ctx context.Context, path string) *FetchResult {
+	key := commonGitDir(path)
+	f.mu.Lock()
+	shared, started := f.shared[key]
+	if !started {
+		shared = &sharedFetch{done: make(chan struct{})}
+		f.shared[key] = shared
+	}
+	f.mu.Unlock()
+
+	if started {
+		<-shared.done
+		return shared.result
+	}
+	shared.result = fetchRepo(ctx, path, f.timeout)
+	close(shared.done)
+	return shared.result
+}
+
+func (f *fetchStage) result(path string) *FetchResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.results[path]
+}
+
+func fetchRepo(ctx context.Context, repoPath string, timeout time.Duration) *FetchResult {
+	_, span := tracing.Tracer().Start(ctx, "fetch",
+		trace.WithAttributes(attribute.String("repo", filepath.Base(repoPath))),
+	)
+	defer span.End()
+
+	start := time.Now()
+	result := &FetchResult{}
+	defer func() {
+		result.Duration = time.Since(start)
+		span.SetAttributes(attribute.String("outcome", string(result.Outcome)))
+	}()
+
+	if remotes, err := gitCommand(ctx, "-C", repoPath, "remote").Output(); err == nil && len(bytes.TrimSpace(remotes)) == 0 {
+		result.Outcome = FetchNoRemote
+		return result
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := gitCommand(fetchCtx, "-C", repoPath, "fetch", "--quiet")
+	cmd.Env = fetchEnv(ctx, repoPath)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	switch {
+	case err == nil:
+		result.Outcome = FetchOK
+	case ctx.Err() != nil:
+		result.Outcome = FetchCancelled
+	case errors.Is(fetchCtx.Err(), context.DeadlineExceeded):
+		result.Outcome = FetchTimeout
+		result.Message = "no response after " + timeout.String()
+	default:
+		result.Outcome = classifyFetchError(stderr.String())
+		result.Message = firstErrorLine(stderr.String())
+	}
+	return result
+}
+
+// fetchEnv stops git and ssh from prompting, which would otherwise hang the
+// scan or interleave password prompts from several repos. A configured ssh
+// command is left alone.
+func fetchEnv(ctx context.Context, repoPath string) []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") != "" || os.Getenv("GIT_SSH") != "" {
+		return env
+	}
+	if out, _ := gitCommand(ctx, "-C", repoPath, "config", "core.sshCommand").Output(); len(bytes.TrimSpace(out)) > 0 {
+		return env
+	}
+	return append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+}
+
+var (
+	authErrors = []string{
+		"authentication failed",
+		"permission denied",
+		"could not read username",
+		"could not read password",
+		"terminal prompts disabled",
+		"host key verification failed",
+		"invalid username or password",
+		"access denied",
+		"repository not found",
+	}
+	networkErrors = []string{
+		"could not resolve host",
+		"could not resolve hostname",
+		"connection refused",
+		"connection timed out",
+		"operation timed out",
+		"network is unreachable",
+		"no route to host",
+		"connection reset",
+		"failed to connect",
+		"ssl",
+		"the remote end hung up",
+	}
+)
+
+// classifyFetchError sorts git's error output into auth and network failures.
+// Auth is checked first: ssh reports a rejected key with both "Permission
+// denied" and the generic "Could not read from remote repository".
+func classifyFetchError(stderr string) FetchOutcome {
+	msg := strings.ToLower(stderr)
+	for _, s := range authErrors {
+		if strings.Contains(msg, s) {
+			return FetchAuth
+		}
+	}
+	for _, s := range networkErrors {
+		if strings.Contains(msg, s) {
+			return FetchNetwork
+		}
+	}
+	return FetchFailed
+}
+
+func firstErrorLine(stderr string) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return strings.TrimPrefix(strings.TrimPrefix(line, "fatal: "), "error: ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClassifyFetchError(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   FetchOutcome
+	}{
+		{"git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.", FetchAuth},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", FetchAuth},
+		{"remote: Invalid username or password.\nfatal: Authentication failed for 'https://example.com/x.git/'", FetchAuth},
+		{"ssh: Could not resolve hostname example.invalid: Name or service not known\nfatal: Could not read from remote repository.", FetchNetwork},
+		{"fatal: unable to access 'https://example.com/x.git/': Failed to connect to example.com port 443: Connection refused", FetchNetwork},
+		{"fatal: '/tmp/nope' does not appear to be a git repository", FetchFailed},
+	}
+	for _, tt := range tests {
+		if got := classifyFetchError(tt.stderr); got != tt.want {
+			t.Errorf("classifyFetchError(%q) = %s, want %s", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestFetchRepoOutcomes(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+	lonely := filepath.Join(root, "lonely")
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, root, "clone", "-q", origin, clone)
+	runGit(t, root, "init", "-q", lonely)
+
+	ctx := context.Background()
+	if got := fetchRepo(ctx, clone, time.Minute); got.Outcome != FetchOK {
+		t.Errorf("clone: %+v, want ok", got)
+	}
+	if got := fetchRepo(ctx, lonely, time.Minute); got.Outcome != FetchNoRemote {
+		t.Errorf("no remote: %+v, want no_remote", got)
+	}
+
+	runGit(t, clone, "remote", "set-url", "origin", filepath.Join(root, "missing"))
+	if got := fetchRepo(ctx, clone, time.Minute); got.Outcome != FetchFailed || got.Message == "" {
+		t.Errorf("missing remote: %+v, want failed with a message", got)
+	}
+
+	runGit(t, clone, "remote", "set-url", "origin", "ssh://git@example.invalid/repo.git")
+	t.Setenv("GIT_SSH_COMMAND", "sh -c 'sleep 10' >/dev/null 2>&1")
+	start := time.Now()
+	if got := fetchRepo(ctx, clone, 200*time.Millisecond); got.Outcome != FetchTimeout {
+		t.Errorf("hung remote: %+v, want timeout", got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timed out fetch took %s", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if got := fetchRepo(cancelled, clone, time.Minute); got.Outcome != FetchCancelled {
+		t.Errorf("cancelled scan: %+v, want cancelled", got)
+	}
+}
+
+func TestFetchStageSharesWorktrees(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+	linked := filepath.Join(root, "clone-feature")
+
+	runGit(t, root, "init", "-q", "-b", "main", origin)
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, root, "clone", "-q", origin, clone)
+	runGit(t, clone, "worktree", "add", "-q", "-b", "feature", linked)
+
+	stage := newFetchStage(ScanConfig{FetchWorkers: 2})
+	paths := make(chan string, 2)
+	paths <- clone
+	paths <- linked
+	close(paths)
+	for range stage.stream(context.Background(), paths) {
+	}
+
+	main, feature := stage.result(clone), stage.result(linked)
+	if main == nil || main.Outcome != FetchOK {
+		t.Fatalf("clone: %+v, want ok", main)
+	}
+	if feature != main {
+		t.Errorf("worktree fetched separately: %+v vs %+v", feature, main)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package core
+
+import "os"
+
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// fileIdentity is unavailable here, so symlink cycle detection and
+// --one-file-system fall back to doing nothing.
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// gitWaitDelay is how long an interrupted git gets to clean up, and how long
+// to wait for children such as ssh to let go of the output pipes, before the
+// command is killed outright.
+const gitWaitDelay = 2 * time.Second
+
+// gitCommand runs git under the scan's context. Cancellation interrupts git
+// rather than killing it, so it removes any lock files it holds, and
+// --no-optional-locks stops read-only commands like status from taking the
+// index lock in the first place.
+func gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--no-optional-locks"}, args...)...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = gitWaitDelay
+	return cmd
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+const gitDirPrefix = "gitdir:"
+
+func openRepo(repoPath string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{
+		EnableDotGitCommonDir: true,
+	})
+}
+
+// readGitFile parses a .git file as written by `git worktree add`, submodules
+// and --separate-git-dir clones, returning the absolute git dir it points to.
+func readGitFile(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	target, ok := strings.CutPrefix(string(line), gitDirPrefix)
+	if !ok {
+		return "", false
+	}
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return filepath.Clean(target), true
+}
+
+func resolveGitDir(repoPath string) (string, bool) {
+	dotGit := filepath.Join(repoPath, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return absPath(dotGit), true
+	}
+	return readGitFile(dotGit)
+}
+
+func readCommonDir(gitDir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return "", false
+	}
+	common := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+	return filepath.Clean(common), true
+}
+
+// commonGitDir identifies the repository at repoPath by the git dir all of its
+// worktrees share: the main .git directory for linked worktrees, the module
+// dir for submodules and repoPath itself for bare repos.
+func commonGitDir(repoPath string) string {
+	gitDir, ok := resolveGitDir(repoPath)
+	if !ok {
+		return repoKey(repoPath)
+	}
+	if common, ok := readCommonDir(gitDir); ok {
+		gitDir = common
+	}
+	return repoKey(gitDir)
+}
+
+func worktreeOf(gitDir string) string {
+	if filepath.Base(gitDir) == ".git" {
+		return filepath.Dir(gitDir)
+	}
+	return gitDir
+}
+
+func findSuperproject(repoPath string) (string, string, bool) {
+	dir := filepath.Dir(absPath(repoPath))
+	for {
+		if gitDir, ok := resolveGitDir(dir); ok {
+			return dir, gitDir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
+
+func looksLikeGitDir(dir string) bool {
+	head, err := os.Stat(filepath.Join(dir, "HEAD"))
+	if err != nil || !head.Mode().IsRegular() {
+		return false
+	}
+	for _, sub := range []string{"objects", "refs"} {
+		info, err := os.Stat(filepath.Join(dir, sub))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// isBareRepo rejects git dirs with core.bare=false, which are the targets of
+// --separate-git-dir clones rather than repositories in their own right.
+func isBareRepo(dir string) bool {
+	if !looksLikeGitDir(dir) {
+		return false
+	}
+	f, err := os.Open(filepath.Join(dir, "config"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	cfg, err := config.ReadConfig(f)
+	if err != nil {
+		return false
+	}
+	return cfg.Core.IsBare
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func isWithin(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
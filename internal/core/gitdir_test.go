@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeLayout(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("main/.git/HEAD", "ref: refs/heads/main\n")
+	write("main/.git/worktrees/feature/commondir", "../..\n")
+	write("feature/.git", "gitdir: ../main/.git/worktrees/feature\n")
+	write("main/.git/modules/lib/HEAD", "ref: refs/heads/main\n")
+	write("main/libs/lib/.git", "gitdir: ../../.git/modules/lib\n")
+	write("separate.git/HEAD", "ref: refs/heads/main\n")
+	write("separate/.git", "gitdir: "+filepath.Join(root, "separate.git")+"\n")
+
+	tests := []struct {
+		path   string
+		kind   WorktreeKind
+		parent string
+	}{
+		{"main", WorktreeMain, ""},
+		{"feature", WorktreeLinked, "main"},
+		{"main/libs/lib", WorktreeSubmodule, "main"},
+		{"separate", WorktreeMain, ""},
+	}
+
+	a := NewAnalyzer(ScanConfig{})
+	for _, tt := range tests {
+		status := &RepoStatus{}
+		a.analyzeLayout(filepath.Join(root, tt.path), status)
+
+		wantParent := ""
+		if tt.parent != "" {
+			wantParent = filepath.Join(root, tt.parent)
+		}
+		if status.Worktree != tt.kind || status.Parent != wantParent {
+			t.Errorf("%s: got %s parent=%q, want %s parent=%q", tt.path, status.Worktree, status.Parent, tt.kind, wantParent)
+		}
+	}
+}
+
+func TestBareRepos(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	bare := filepath.Join(root, "repos", "bare.git")
+	mirror := filepath.Join(root, "repos", "mirror.git")
+	runGit(t, root, "init", "-q", "-b", "main", work)
+	runGit(t, work, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, root, "init", "-q", "--bare", bare)
+	runGit(t, root, "clone", "-q", "--mirror", work, mirror)
+
+	if isBareRepo(filepath.Join(work, ".git")) {
+		t.Error("isBareRepo accepted a working repo's .git")
+	}
+
+	s := NewScanner(ScanConfig{RootPath: filepath.Join(root, "repos"), IgnoreFile: filepath.Join(root, "none")})
+	found, err := s.findRepos(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found.repos) != 2 {
+		t.Fatalf("found %v, want the bare repo and the mirror", found.repos)
+	}
+
+	for _, skip := range [][]string{nil, {"bare_size"}} {
+		analyzer := NewAnalyzer(ScanConfig{DisableProbes: skip})
+		for path, want := range map[string]RepoKind{bare: KindBare, mirror: KindMirror} {
+			if !isBareRepo(path) {
+				t.Errorf("isBareRepo(%s) = false", path)
+			}
+			status, err := analyzer.Analyze(context.Background(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Kind != want || status.Bare == nil {
+				t.Fatalf("%s: kind = %s, bare = %v; want %s", status.Name, status.Kind, status.Bare, want)
+			}
+			if sized := status.Bare.SizeBytes > 0; sized != (skip == nil) {
+				t.Errorf("%s skipping %v: size = %d", status.Name, skip, status.Bare.SizeBytes)
+			}
+		}
+		mirrored, _ := analyzer.Analyze(context.Background(), mirror)
+		if mirrored.Bare.MirrorRemote != work || mirrored.Bare.Branches != 1 || mirrored.Bare.LastFetch.IsZero() {
+			t.Errorf("mirror = %+v, want one branch mirrored from %s and the clone as its last fetch", mirrored.Bare, work)
+		}
+	}
+}
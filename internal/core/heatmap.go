@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+const heatmapWeeks = 52
+
+// heatmapStart is the Sunday that begins a 52-week grid ending in the week of
+// today, so the grid always has full columns and today in the last one.
+func heatmapStart(today time.Time) time.Time {
+	thisWeek := today.AddDate(0, 0, -int(today.Weekday()))
+	return thisWeek.AddDate(0, 0, -7*heatmapWeeks)
+}
+
+func newHeatmap(start, today time.Time) *Heatmap {
+	return &Heatmap{Start: start.Format(dateLayout), Days: make([]int, daysBetween(start, today)+1)}
+}
+
+func (h *Heatmap) add(day time.Time, n int) {
+	start, err := time.Parse(dateLayout, h.Start)
+	if err != nil {
+		return
+	}
+	idx := daysBetween(start, day)
+	if idx < 0 || idx >= len(h.Days) {
+		return
+	}
+	h.Days[idx] += n
+	h.Total += n
+	if h.Days[idx] > h.Max {
+		h.Max = h.Days[idx]
+	}
+}
+
+func (a *Analyzer) analyzeHeatmap(ctx context.Context, repo *git.Repository, repoPath string, authors *authorMatcher, status *RepoStatus) {
+	today := a.clock.today()
+	start := heatmapStart(today)
+	commits, err := a.commitDays(ctx, repo, repoPath, authors, a.clock.start(start))
+	if err != nil {
+		return
+	}
+
+	heatmap := newHeatmap(start, today)
+	for _, day := range commits {
+		heatmap.add(day, 1)
+	}
+	status.Heatmap = heatmap
+	status.heatmapCommits = commits
+}
+
+// mergeHeatmaps builds the tree-wide grid from the repos' commits, counting a
+// commit reached from several worktrees or clones once, as tallyActivity does.
+func mergeHeatmaps(statuses []RepoStatus, clock dayClock) *Heatmap {
+	var tree *Heatmap
+	seen := make(map[string]bool)
+	for _, i := range tallyOrder(statuses) {
+		st := statuses[i]
+		if st.Heatmap == nil {
+			continue
+		}
+		if tree == nil {
+			today := clock.today()
+			tree = newHeatmap(heatmapStart(today), today)
+		}
+		for hash, day := range st.heatmapCommits {
+			if !seen[hash] {
+				seen[hash] = true
+				tree.add(day, 1)
+			}
+		}
+	}
+	return tree
+}
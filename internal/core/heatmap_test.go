@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHeatmapGrid(t *testing.T) {
+	today := time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local)
+	start := heatmapStart(today)
+	if start.Weekday() != time.Sunday || start.Format(dateLayout) != "2025-10-12" {
+		t.Fatalf("start = %s (%s), want Sunday 2025-10-12", start.Format(dateLayout), start.Weekday())
+	}
+
+	h := newHeatmap(start, today)
+	if len(h.Days) != 52*7+5 {
+		t.Fatalf("got %d days, want %d", len(h.Days), 52*7+5)
+	}
+
+	h.add(time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local), 2)
+	h.add(start, 1)
+	h.add(start.AddDate(0, 0, -1), 5)
+	if h.Days[len(h.Days)-1] != 2 || h.Days[0] != 1 || h.Total != 3 || h.Max != 2 {
+		t.Errorf("heatmap = first %d last %d total %d max %d", h.Days[0], h.Days[len(h.Days)-1], h.Total, h.Max)
+	}
+}
+
+func TestMergeHeatmapsCountsSharedCommitsOnce(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	main := filepath.Join(root, "repo")
+	runGit(t, root, "init", "-q", "-b", "main", main)
+	runGit(t, main, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, main, "branch", "idea")
+	runGit(t, main, "worktree", "add", "-q", filepath.Join(root, "repo-idea"), "idea")
+	runGit(t, filepath.Join(root, "repo-idea"), "commit", "-q", "--allow-empty", "-m", "side branch")
+	runGit(t, root, "clone", "-q", main, filepath.Join(root, "clone"))
+	runGit(t, root, "clone", "-q", "--mirror", main, filepath.Join(root, "mirror.git"))
+
+	analyzer := NewAnalyzer(ScanConfig{Heatmap: true})
+	want := map[string]int{"repo": 2, "repo-idea": 2, "clone": 1, "mirror.git": 2}
+	var statuses []RepoStatus
+	for name, total := range want {
+		status, err := analyzer.Analyze(context.Background(), filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Heatmap == nil || status.Heatmap.Total != total {
+			t.Fatalf("%s: heatmap = %+v, want %d commits over all branches", name, status.Heatmap, total)
+		}
+		statuses = append(statuses, *status)
+	}
+
+	if tree := mergeHeatmaps(statuses, analyzer.clock); tree.Total != 2 || tree.Max != 2 {
+		t.Errorf("tree heatmap total = %d, max = %d; want each commit once", tree.Total, tree.Max)
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const IgnoreFileName = ".pulseignore"
+
+var defaultIgnorePatterns = []string{
+	"node_modules",
+	".Trash",
+	"vendor",
+}
+
+func DefaultIgnoreFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "pulse", "ignore")
+}
+
+type ignoreRule struct {
+	text    string
+	source  string
+	pattern gitignore.Pattern
+}
+
+// ignoreMatcher layers the built-in defaults, the global ignore file and every
+// .pulseignore between the scan root and a path. Later rules take precedence,
+// so a deeper .pulseignore can re-include what the global file excludes.
+type ignoreMatcher struct {
+	root string
+	base []ignoreRule
+
+	mu   sync.Mutex
+	dirs map[string][]ignoreRule
+}
+
+func newIgnoreMatcher(root string, globalFile string) *ignoreMatcher {
+	root = absPath(root)
+	domain := splitPath(root)
+
+	m := &ignoreMatcher{
+		root: root,
+		dirs: make(map[string][]ignoreRule),
+	}
+	for _, p := range defaultIgnorePatterns {
+		m.base = append(m.base, ignoreRule{
+			text:    p,
+			source:  "default",
+			pattern: gitignore.ParsePattern(p, domain),
+		})
+	}
+	if globalFile != "" {
+		m.base = append(m.base, readIgnoreFile(globalFile, domain)...)
+	}
+	return m
+}
+
+func (m *ignoreMatcher) match(path string, isDir bool) *ignoreRule {
+	path = absPath(path)
+	if path == m.root {
+		return nil
+	}
+
+	rules := append([]ignoreRule(nil), m.base...)
+	for _, dir := range m.ancestors(path) {
+		rules = append(rules, m.dirRules(dir)...)
+	}
+
+	parts := splitPath(path)
+	for i := len(rules) - 1; i >= 0; i-- {
+		switch rules[i].pattern.Match(parts, isDir) {
+		case gitignore.Exclude:
+			return &rules[i]
+		case gitignore.Include:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *ignoreMatcher) ancestors(path string) []string {
+	rel, err := filepath.Rel(m.root, filepath.Dir(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	dirs := []string{m.root}
+	if rel == "." {
+		return dirs
+	}
+	current := m.root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		dirs = append(dirs, current)
+	}
+	return dirs
+}
+
+func (m *ignoreMatcher) dirRules(dir string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.dirs[dir]; ok {
+		return rules
+	}
+	rules := readIgnoreFile(filepath.Join(dir, IgnoreFileName), splitPath(dir))
+	m.dirs[dir] = rules
+	return rules
+}
+
+func readIgnoreFile(path string, domain []string) []ignoreRule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := sc.Text()
+		if strings.HasPrefix(text, "#") || strings.TrimSpace(text) == "" {
+			continue
+		}
+		rules = append(rules, ignoreRule{
+			text:    text,
+			source:  fmt.Sprintf("%s:%d", path, line),
+			pattern: gitignore.ParsePattern(text, domain),
+		})
+	}
+	return rules
+}
+
+func splitPath(path string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	global := filepath.Join(t.TempDir(), "ignore")
+	os.WriteFile(global, []byte("# comment\n.venv\n/archive\n"), 0o644)
+	os.WriteFile(filepath.Join(root, IgnoreFileName), []byte("target/\n"), 0o644)
+	os.MkdirAll(filepath.Join(root, "keep"), 0o755)
+	os.WriteFile(filepath.Join(root, "keep", IgnoreFileName), []byte("!vendor\n!.venv\n"), 0o644)
+
+	m := newIgnoreMatcher(root, global)
+
+	tests := []struct {
+		path   string
+		want   string
+		source string
+	}{
+		{"node_modules", "node_modules", "default"},
+		{"a/vendor", "vendor", "default"},
+		{"keep/vendor", "", ""},
+		{"a/.venv", ".venv", global + ":2"},
+		{"keep/.venv", "", ""},
+		{"archive", "/archive", global + ":3"},
+		{"a/archive", "", ""},
+		{"a/b/target", "target/", filepath.Join(root, IgnoreFileName) + ":1"},
+		{"src", "", ""},
+	}
+
+	for _, tt := range tests {
+		rule := m.match(filepath.Join(root, tt.path), true)
+		switch {
+		case tt.want == "" && rule != nil:
+			t.Errorf("%s: excluded by %q, want included", tt.path, rule.text)
+		case tt.want != "" && rule == nil:
+			t.Errorf("%s: included, want excluded by %q", tt.path, tt.want)
+		case rule != nil && (rule.text != tt.want || rule.source != tt.source):
+			t.Errorf("%s: excluded by %q (%s), want %q (%s)", tt.path, rule.text, rule.source, tt.want, tt.source)
+		}
+	}
+
+	if m.match(root, true) != nil {
+		t.Errorf("root must never be ignored")
+	}
+
+	t.Chdir(root)
+	rel := newIgnoreMatcher(".", global)
+	if rule := rel.match(filepath.Join("a", "b", "target"), true); rule == nil || rule.text != "target/" {
+		t.Errorf("relative root: a/b/target not excluded by target/")
+	}
+}
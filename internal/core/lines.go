@@ -0,0 +1,223 @@
+package core
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var languages = map[string]string{
+	".go":    "Go",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".py":    "Python",
+	".rs":    "Rust",
+	".rb":    "Ruby",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".php":   "PHP",
+	".lua":   "Lua",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".zsh":   "Shell",
+	".sql":   "SQL",
+	".proto": "Protocol Buffers",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".md":    "Markdown",
+	".json":  "JSON",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".toml":  "TOML",
+}
+
+type fileChange struct {
+	path           string
+	added, removed int
+	author, email  string
+}
+
+// analyzeLinesChanged sums `git log --numstat` over the activity window.
+// Merges are skipped unless asked for, since their diff against the first
+// parent repeats work already counted on the merged branch; when included,
+// that first-parent diff is what counts. Files marked
+// linguist-generated or linguist-vendored, or matching an exclude pattern,
+// are tallied separately so a vendoring commit doesn't drown real work.
+func (a *Analyzer) analyzeLinesChanged(ctx context.Context, repoPath string, authors *authorMatcher, status *RepoStatus) {
+	since := a.clock.start(a.clock.today().AddDate(0, 0, -(a.activityDays - 1)))
+	args := []string{"-C", repoPath, "log", "-z", "--numstat", "--format=%x1e%an%x00%ae",
+		"--since=" + since.Format(time.RFC3339)}
+	if a.includeMerges {
+		args = append(args, "--diff-merges=first-parent")
+	} else {
+		args = append(args, "--no-merges")
+	}
+	out, err := gitCommand(ctx, args...).Output()
+	if err != nil {
+		return
+	}
+
+	var changes []fileChange
+	for _, change := range parseNumstat(out) {
+		if authors.match(object.Signature{Name: change.author, Email: change.email}) {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	skipped := linguistExcluded(ctx, repoPath, changes)
+	lines := &LinesChanged{Period: time.Duration(a.activityDays) * 24 * time.Hour}
+	byLanguage := make(map[string]*LanguageLines)
+	for _, change := range changes {
+		if skipped[change.path] || matchesAny(a.linesExclude, change.path) {
+			lines.ExcludedAdded += change.added
+			lines.ExcludedRemoved += change.removed
+			continue
+		}
+		lines.Added += change.added
+		lines.Removed += change.removed
+
+		lang := languageOf(change.path)
+		if byLanguage[lang] == nil {
+			byLanguage[lang] = &LanguageLines{Language: lang}
+		}
+		byLanguage[lang].Added += change.added
+		byLanguage[lang].Removed += change.removed
+	}
+
+	for _, l := range byLanguage {
+		lines.ByLanguage = append(lines.ByLanguage, *l)
+	}
+	sort.Slice(lines.ByLanguage, func(i, j int) bool {
+		ti := lines.ByLanguage[i].Added + lines.ByLanguage[i].Removed
+		tj := lines.ByLanguage[j].Added + lines.ByLanguage[j].Removed
+		if ti != tj {
+			return ti > tj
+		}
+		return lines.ByLanguage[i].Language < lines.ByLanguage[j].Language
+	})
+
+	if lines.Added+lines.Removed+lines.ExcludedAdded+lines.ExcludedRemoved > 0 {
+		status.LinesChanged = lines
+	}
+}
+
+// parseNumstat reads `git log -z --numstat --format=%x1e%an%x00%ae`. Each
+// commit starts with a record separator; renames put an empty path after the
+// counts and follow it with the old and new paths. Binary files, shown as
+// "-", have no line counts and are skipped.
+func parseNumstat(out []byte) []fileChange {
+	var changes []fileChange
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(record, "\x00")
+		if len(fields) < 2 {
+			continue
+		}
+		author, email := fields[0], fields[1]
+		for i := 2; i < len(fields); i++ {
+			counts := strings.SplitN(strings.TrimLeft(fields[i], "\n"), "\t", 3)
+			if len(counts) < 3 {
+				continue
+			}
+			p := counts[2]
+			if p == "" && i+2 < len(fields) {
+				p = fields[i+2]
+				i += 2
+			}
+			added, errA := strconv.Atoi(counts[0])
+			removed, errR := strconv.Atoi(counts[1])
+			if errA != nil || errR != nil {
+				continue
+			}
+			changes = append(changes, fileChange{path: p, added: added, removed: removed, author: author, email: email})
+		}
+	}
+	return changes
+}
+
+// linguistExcluded asks git which of the changed paths .gitattributes marks
+// as generated or vendored.
+func linguistExcluded(ctx context.Context, repoPath string, changes []fileChange) map[string]bool {
+	var input strings.Builder
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if !seen[change.path] {
+			seen[change.path] = true
+			input.WriteString(change.path)
+			input.WriteByte(0)
+		}
+	}
+
+	cmd := gitCommand(ctx, "-C", repoPath, "check-attr", "-z", "--stdin",
+		"linguist-generated", "linguist-vendored")
+	cmd.Stdin = strings.NewReader(input.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	excluded := make(map[string]bool)
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		if value := fields[i+2]; value == "set" || value == "true" {
+			excluded[fields[i]] = true
+		}
+	}
+	return excluded
+}
+
+func parseExcludes(patterns []string) []gitignore.Pattern {
+	var parsed []gitignore.Pattern
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			parsed = append(parsed, gitignore.ParsePattern(p, nil))
+		}
+	}
+	return parsed
+}
+
+func matchesAny(patterns []gitignore.Pattern, file string) bool {
+	parts := strings.Split(file, "/")
+	for _, p := range patterns {
+		if p.Match(parts, false) == gitignore.Exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// languageOf names the language for common extensions and falls back to the
+// extension itself, or the file name for files like Makefile.
+func languageOf(file string) string {
+	base := path.Base(file)
+	ext := strings.ToLower(path.Ext(base))
+	if lang, ok := languages[ext]; ok {
+		return lang
+	}
+	if ext != "" && ext != base {
+		return ext
+	}
+	return base
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeLinesChanged(t *testing.T) {
+	requireGit(t)
+	repo := t.TempDir()
+	write := func(name string, lines int) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x\n", lines)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runGit(t, repo, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.pb.go linguist-generated\nthird_party/** linguist-vendored\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	write("main.go", 10)
+	write("api.pb.go", 500)
+	write("third_party/lib.go", 300)
+	write("go.sum", 40)
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	runGit(t, repo, "checkout", "-q", "-b", "feature")
+	write("app.ts", 7)
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "feature")
+	runGit(t, repo, "checkout", "-q", "main")
+	write("main.go", 12)
+	runGit(t, repo, "commit", "-q", "-am", "main")
+	runGit(t, repo, "merge", "-q", "--no-ff", "-m", "merge", "feature")
+
+	a := NewAnalyzer(ScanConfig{DetailMode: true, LinesExclude: []string{"go.sum"}})
+	status := &RepoStatus{}
+	a.analyzeLinesChanged(context.Background(), repo, nil, status)
+
+	got := status.LinesChanged
+	if got == nil {
+		t.Fatal("expected lines changed")
+	}
+	// .gitattributes 2, main.go 10 then 2, app.ts 7; the merge repeats app.ts.
+	if got.Added != 21 || got.Removed != 0 {
+		t.Errorf("lines = +%d -%d, want +21 -0", got.Added, got.Removed)
+	}
+	if got.ExcludedAdded != 840 {
+		t.Errorf("excluded = %d, want 840 (generated, vendored and go.sum)", got.ExcludedAdded)
+	}
+	want := []LanguageLines{
+		{Language: "Go", Added: 12},
+		{Language: "TypeScript", Added: 7},
+		{Language: ".gitattributes", Added: 2},
+	}
+	if !reflect.DeepEqual(got.ByLanguage, want) {
+		t.Errorf("by language = %+v, want %+v", got.ByLanguage, want)
+	}
+
+	a = NewAnalyzer(ScanConfig{DetailMode: true, LinesExclude: []string{"go.sum"}, IncludeMerges: true})
+	status = &RepoStatus{}
+	a.analyzeLinesChanged(context.Background(), repo, nil, status)
+	if status.LinesChanged.Added != 28 {
+		t.Errorf("with merges = +%d, want +28", status.LinesChanged.Added)
+	}
+}
+
+func TestParseNumstatRename(t *testing.T) {
+	out := "\x1eJane\x00jane@example.com\x00\n3\t1\t\x00old/name.go\x00new/name.go\x00-\t-\tlogo.png\x00"
+	got := parseNumstat([]byte(out))
+	want := []fileChange{{path: "new/name.go", added: 3, removed: 1, author: "Jane", email: "jane@example.com"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNumstat = %+v, want %+v", got, want)
+	}
+}
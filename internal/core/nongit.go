@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	classifyMaxDepth   = 4
+	classifyMaxEntries = 2000
+)
+
+var projectManifests = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"Cargo.toml":       true,
+	"pyproject.toml":   true,
+	"setup.py":         true,
+	"requirements.txt": true,
+	"Gemfile":          true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"composer.json":    true,
+	"mix.exs":          true,
+	"Package.swift":    true,
+	"CMakeLists.txt":   true,
+	"Makefile":         true,
+	"deno.json":        true,
+	"pubspec.yaml":     true,
+}
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".rs": true, ".py": true, ".js": true, ".jsx": true,
+	".ts": true, ".tsx": true, ".java": true, ".kt": true, ".swift": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true,
+	".cs": true, ".rb": true, ".php": true, ".ex": true, ".exs": true,
+	".scala": true, ".clj": true, ".hs": true, ".lua": true, ".sh": true,
+	".zig": true, ".dart": true, ".vue": true, ".svelte": true,
+}
+
+var errClassifyLimit = errors.New("classify limit reached")
+
+// classifyNonGit looks inside a directory that is not under version control to
+// tell a forgotten project from a downloads folder. The walk is bounded in both
+// depth and entries, and Partial reports when a bound was hit. Ignored
+// directories such as node_modules are skipped, so they don't count towards
+// the size. It also returns the mtime of every directory it read, which is
+// what the discovery cache checks before reusing the result.
+func classifyNonGit(dir string, ignore *ignoreMatcher) (NonGitDir, map[string]int64) {
+	info := NonGitDir{Kind: NonGitOther}
+	manifests := make(map[string]bool)
+	dirs := make(map[string]int64)
+	baseDepth := strings.Count(dir, string(filepath.Separator))
+	entries := 0
+
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != dir {
+			entries++
+			if entries > classifyMaxEntries {
+				info.Partial = true
+				return errClassifyLimit
+			}
+		}
+
+		if d.IsDir() {
+			if path != dir {
+				if d.Name() == ".git" || ignore.match(path, true) != nil {
+					return filepath.SkipDir
+				}
+				if strings.Count(path, string(filepath.Separator))-baseDepth >= classifyMaxDepth {
+					info.Partial = true
+					return filepath.SkipDir
+				}
+			}
+			if fi, err := d.Info(); err == nil {
+				dirs[path] = fi.ModTime().UnixNano()
+			}
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		info.SizeBytes += fi.Size()
+		if fi.ModTime().After(info.LastModified) {
+			info.LastModified = fi.ModTime()
+		}
+		if projectManifests[d.Name()] {
+			manifests[d.Name()] = true
+		}
+		if sourceExtensions[filepath.Ext(d.Name())] {
+			info.SourceFiles++
+		}
+		return nil
+	})
+
+	for m := range manifests {
+		info.Manifests = append(info.Manifests, m)
+	}
+	sort.Strings(info.Manifests)
+
+	switch {
+	case len(info.Manifests) > 0:
+		info.Kind = NonGitProject
+	case info.SourceFiles > 0:
+		info.Kind = NonGitSource
+	}
+	return info, dirs
+}
+
+func (d NonGitDir) LikelyProject() bool {
+	return d.Kind == NonGitProject || d.Kind == NonGitSource
+}
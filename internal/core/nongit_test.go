@@ -0,0 +1,72 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyNonGit(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("tool/go.mod", "module tool\n")
+	write("tool/main.go", "package main\n")
+	write("tool/node_modules/dep/index.js", "module.exports = {}\n")
+	write("scripts/deploy.sh", "#!/bin/sh\n")
+	write("downloads/movie.mkv", "0123456789")
+
+	ignore := newIgnoreMatcher(root, filepath.Join(root, "none"))
+	tests := []struct {
+		dir       string
+		kind      NonGitKind
+		sources   int
+		manifests int
+	}{
+		{"tool", NonGitProject, 1, 1},
+		{"scripts", NonGitSource, 1, 0},
+		{"downloads", NonGitOther, 0, 0},
+	}
+	for _, tt := range tests {
+		got, _ := classifyNonGit(filepath.Join(root, tt.dir), ignore)
+		if got.Kind != tt.kind || got.SourceFiles != tt.sources || len(got.Manifests) != tt.manifests {
+			t.Errorf("%s: got %+v, want kind %s with %d sources and %d manifests", tt.dir, got, tt.kind, tt.sources, tt.manifests)
+		}
+		if got.SizeBytes == 0 || got.LastModified.IsZero() {
+			t.Errorf("%s: missing size or mtime: %+v", tt.dir, got)
+		}
+	}
+}
+
+func TestDiscoveryCacheClassify(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "tool")
+	os.MkdirAll(filepath.Join(dir, "cmd"), 0o755)
+	os.WriteFile(filepath.Join(dir, "cmd", "main.go"), []byte("package main\n"), 0o644)
+	notes := filepath.Join(dir, "notes.txt")
+	os.WriteFile(notes, []byte("x"), 0o644)
+
+	ignore := newIgnoreMatcher(root, filepath.Join(root, "none"))
+	cache := loadDiscoveryCache("", false)
+	first := cache.classify(dir, ignore)
+	if first.Kind != NonGitSource {
+		t.Fatalf("first = %+v, want source", first)
+	}
+
+	// An edit in place changes no directory's mtime, so the result is reused.
+	os.WriteFile(notes, []byte("a much longer note"), 0o644)
+	if again := cache.classify(dir, ignore); again.SizeBytes != first.SizeBytes {
+		t.Errorf("size = %d, want the cached %d", again.SizeBytes, first.SizeBytes)
+	}
+
+	// Removing a file from a subdirectory changes that directory's mtime.
+	os.Remove(filepath.Join(dir, "cmd", "main.go"))
+	if changed := cache.classify(dir, ignore); changed.Kind != NonGitOther {
+		t.Errorf("after removing main.go: %+v, want other", changed)
+	}
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// analyzeOperations looks for the state files git leaves in the worktree's git
+// dir while an operation is stopped half way. Linked worktrees keep these in
+// their own git dir, not the common one.
+func (a *Analyzer) analyzeOperations(repoPath string, status *RepoStatus) {
+	gitDir, ok := resolveGitDir(repoPath)
+	if !ok {
+		return
+	}
+	path := func(name string) string { return filepath.Join(gitDir, name) }
+
+	if dir := path("rebase-merge"); isDir(dir) {
+		op := Operation{
+			Kind:    OpRebase,
+			Step:    readInt(filepath.Join(dir, "msgnum")),
+			Total:   readInt(filepath.Join(dir, "end")),
+			Branch:  readHeadName(filepath.Join(dir, "head-name")),
+			Started: modTime(filepath.Join(dir, "head-name")),
+		}
+		status.Operations = append(status.Operations, op)
+	} else if dir := path("rebase-apply"); isDir(dir) {
+		op := Operation{
+			Kind:    OpRebase,
+			Step:    readInt(filepath.Join(dir, "next")),
+			Total:   readInt(filepath.Join(dir, "last")),
+			Branch:  readHeadName(filepath.Join(dir, "head-name")),
+			Started: modTime(filepath.Join(dir, "last")),
+		}
+		if pathExists(filepath.Join(dir, "applying")) {
+			op.Kind = OpApply
+		}
+		status.Operations = append(status.Operations, op)
+	}
+
+	for _, marker := range []struct {
+		file string
+		kind OperationKind
+	}{
+		{"MERGE_HEAD", OpMerge},
+		{"CHERRY_PICK_HEAD", OpCherryPick},
+		{"REVERT_HEAD", OpRevert},
+	} {
+		if started := modTime(path(marker.file)); !started.IsZero() {
+			status.Operations = append(status.Operations, Operation{Kind: marker.kind, Started: started})
+		}
+	}
+
+	if pathExists(path("BISECT_LOG")) {
+		op := Operation{
+			Kind:    OpBisect,
+			Step:    countBisectSteps(path("BISECT_LOG")),
+			Branch:  readBisectStart(path("BISECT_START")),
+			Started: modTime(path("BISECT_START")),
+		}
+		status.Operations = append(status.Operations, op)
+	}
+}
+
+// countBisectSteps counts the good, bad and skip verdicts recorded so far.
+func countBisectSteps(logPath string) int {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	steps := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "git bisect ") && !strings.HasPrefix(line, "git bisect start") {
+			steps++
+		}
+	}
+	return steps
+}
+
+// readBisectStart returns the branch the bisect started from, or the short
+// commit hash if HEAD was detached.
+func readBisectStart(path string) string {
+	start := strings.TrimSpace(readString(path))
+	if len(start) == 40 && strings.Trim(start, "0123456789abcdef") == "" {
+		return shortHash(start)
+	}
+	return start
+}
+
+func readHeadName(path string) string {
+	name := strings.TrimSpace(readString(path))
+	if name == "detached HEAD" {
+		return ""
+	}
+	return strings.TrimPrefix(name, "refs/heads/")
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func readInt(path string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(readString(path)))
+	return n
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
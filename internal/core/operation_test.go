@@ -0,0 +1,48 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeOperations(t *testing.T) {
+	repoPath := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repoPath, ".git", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("rebase-merge/head-name", "refs/heads/feature\n")
+	write("rebase-merge/msgnum", "3\n")
+	write("rebase-merge/end", "7\n")
+	write("MERGE_HEAD", "0123456789abcdef0123456789abcdef01234567\n")
+	write("BISECT_START", "main\n")
+	write("BISECT_LOG", "git bisect start\n# bad: [abc] x\ngit bisect bad abc\n# good: [def] y\ngit bisect good def\n")
+
+	status := &RepoStatus{}
+	NewAnalyzer(ScanConfig{}).analyzeOperations(repoPath, status)
+
+	want := []Operation{
+		{Kind: OpRebase, Step: 3, Total: 7, Branch: "feature"},
+		{Kind: OpMerge},
+		{Kind: OpBisect, Step: 2, Branch: "main"},
+	}
+	if len(status.Operations) != len(want) {
+		t.Fatalf("got %+v, want %+v", status.Operations, want)
+	}
+	for i, op := range status.Operations {
+		if op.Started.IsZero() {
+			t.Errorf("%s: missing start time", op.Kind)
+		}
+		op.Started = want[i].Started
+		if op != want[i] {
+			t.Errorf("operation %d = %+v, want %+v", i, op, want[i])
+		}
+	}
+}
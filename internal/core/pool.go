@@ -2,6 +2,9 @@ package core
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
+	"sort"
 	"sync"
 )
 
@@ -13,52 +16,103 @@ func NewPool(workerCount int) *Pool {
 	return &Pool{workerCount: workerCount}
 }
 
-func (p *Pool) Process(ctx context.Context, repoPaths []string, analyzer *Analyzer) ([]RepoStatus, []ScanError) {
+func (p *Pool) Process(ctx context.Context, repoPaths []string, analyzer *Analyzer) ([]RepoStatus, []ScanError, []UnfinishedRepo) {
+	jobs := make(chan string, len(repoPaths))
+	for _, path := range repoPaths {
+		jobs <- path
+	}
+	close(jobs)
+
+	return p.Stream(ctx, jobs, analyzer)
+}
+
+// Stream analyzes repos as they arrive. Once ctx is done, repos still queued
+// are reported as unfinished without being opened, and the channel is drained
+// so discovery can finish.
+func (p *Pool) Stream(ctx context.Context, repoPaths <-chan string, analyzer *Analyzer) ([]RepoStatus, []ScanError, []UnfinishedRepo) {
 	type result struct {
-		status *RepoStatus
-		err    *ScanError
+		status     *RepoStatus
+		err        *ScanError
+		unfinished *UnfinishedRepo
 	}
 
-	jobs := make(chan string, len(repoPaths))
-	results := make(chan result, len(repoPaths))
+	results := make(chan result, p.workerCount)
 
 	var wg sync.WaitGroup
 	for i := 0; i < p.workerCount; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for path := range jobs {
+			for path := range repoPaths {
+				if err := ctx.Err(); err != nil {
+					results <- result{unfinished: &UnfinishedRepo{Name: filepath.Base(path), Path: path, Reason: stopReason(err)}}
+					continue
+				}
 				status, err := analyzer.Analyze(ctx, path)
-				if err != nil {
+				var stop *stopError
+				switch {
+				case errors.As(err, &stop):
+					results <- result{unfinished: &UnfinishedRepo{
+						Name:    filepath.Base(path),
+						Path:    path,
+						Reason:  stop.reason,
+						Stage:   stop.stage,
+						Elapsed: stop.elapsed,
+					}}
+				case err != nil:
 					results <- result{err: &ScanError{Path: path, Message: err.Error()}}
-				} else {
+				default:
 					results <- result{status: status}
 				}
 			}
 		}()
 	}
 
-	for _, path := range repoPaths {
-		jobs <- path
-	}
-	close(jobs)
-
 	go func() {
 		wg.Wait()
 		close(results)
 	}()
 
 	var statuses []RepoStatus
-	var errors []ScanError
+	var scanErrors []ScanError
+	var unfinished []UnfinishedRepo
 	for r := range results {
 		if r.status != nil {
 			statuses = append(statuses, *r.status)
 		}
 		if r.err != nil {
-			errors = append(errors, *r.err)
+			scanErrors = append(scanErrors, *r.err)
+		}
+		if r.unfinished != nil {
+			unfinished = append(unfinished, *r.unfinished)
 		}
 	}
 
 	SortByLastActive(statuses)
-	return statuses, errors
+	sort.Slice(unfinished, func(i, j int) bool { return unfinished[i].Path < unfinished[j].Path })
+	return statuses, scanErrors, unfinished
+}
+
+// Each calls fn with every index below n, spread over the pool's workers.
+func (p *Pool) Each(ctx context.Context, n int, fn func(i int)) {
+	jobs := make(chan int, n)
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.workerCount, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
 }
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStreamReportsUnfinished(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	runGit(t, root, "init", "-q", repoPath)
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "base")
+
+	analyzer := NewAnalyzer(ScanConfig{})
+	pool := NewPool(1)
+
+	statuses, _, unfinished := pool.Process(context.Background(), []string{repoPath}, analyzer)
+	if len(statuses) != 1 || len(unfinished) != 0 {
+		t.Fatalf("uncancelled scan: %d statuses, %+v unfinished", len(statuses), unfinished)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	statuses, _, unfinished = pool.Process(cancelled, []string{repoPath}, analyzer)
+	want := UnfinishedRepo{Name: "repo", Path: repoPath, Reason: UnfinishedCancelled}
+	if len(statuses) != 0 || len(unfinished) != 1 || unfinished[0] != want {
+		t.Errorf("cancelled scan: %d statuses, %+v unfinished, want %+v", len(statuses), unfinished, want)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	_, err := analyzer.Analyze(expired, repoPath)
+	stop, ok := err.(*stopError)
+	if !ok || stop.reason != UnfinishedTimeout || stop.stage != "plain_open" {
+		t.Errorf("expired deadline: err = %v, want a timeout during plain_open", err)
+	}
+}
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// Cost is a rough guide to how long a probe takes per repo, shown when
+// listing probes so users know which ones are worth turning off.
+type Cost string
+
+const (
+	CostCheap     Cost = "cheap"
+	CostModerate  Cost = "moderate"
+	CostExpensive Cost = "expensive"
+)
+
+// Probe is one step of a repo's analysis. Probes run in dependency order,
+// each under a span named after it. A non-nil result is stored in
+// RepoStatus.Extra under the probe's name; the built-in probes fill in
+// RepoStatus fields directly and return nil.
+type Probe interface {
+	Name() string
+	Deps() []string
+	Cost() Cost
+	Run(ctx context.Context, repo *ProbeRepo) (any, error)
+}
+
+// ProbeRepo is what a probe gets to work with: the opened repository and the
+// status filled in by the probes that ran before it.
+type ProbeRepo struct {
+	Path   string
+	Repo   *git.Repository
+	Status *RepoStatus
+
+	authors *authorMatcher
+}
+
+// ProbeInfo describes a registered probe and whether the scan will run it.
+type ProbeInfo struct {
+	Name    string   `json:"name"`
+	Deps    []string `json:"deps,omitempty"`
+	Cost    Cost     `json:"cost"`
+	Enabled bool     `json:"enabled"`
+	BuiltIn bool     `json:"built_in"`
+}
+
+// NewProbe wraps a function as a Probe.
+func NewProbe(name string, cost Cost, run func(ctx context.Context, repo *ProbeRepo) (any, error), deps ...string) Probe {
+	return &funcProbe{name: name, cost: cost, deps: deps, run: run}
+}
+
+type funcProbe struct {
+	name string
+	cost Cost
+	deps []string
+	run  func(ctx context.Context, repo *ProbeRepo) (any, error)
+
+	// when limits a probe to some kinds of repo, e.g. worktree probes to
+	// working repos; off leaves it out unless enabled by name.
+	when    func(*RepoStatus) bool
+	off     bool
+	builtIn bool
+}
+
+func (p *funcProbe) Name() string   { return p.name }
+func (p *funcProbe) Deps() []string { return p.deps }
+func (p *funcProbe) Cost() Cost     { return p.cost }
+
+func (p *funcProbe) Run(ctx context.Context, repo *ProbeRepo) (any, error) {
+	return p.run(ctx, repo)
+}
+
+func (p *funcProbe) applies(status *RepoStatus) bool {
+	return p.when == nil || p.when(status)
+}
+
+func isWorking(status *RepoStatus) bool { return status.Kind == KindWorking }
+func isBare(status *RepoStatus) bool    { return status.Kind != KindWorking }
+
+// builtinProbes lists pulse's own analyses in the order they have always run.
+func (a *Analyzer) builtinProbes() []Probe {
+	builtin := func(name string, cost Cost, fn func(ctx context.Context, r *ProbeRepo), deps ...string) *funcProbe {
+		return &funcProbe{name: name, cost: cost, deps: deps, builtIn: true, run: func(ctx context.Context, r *ProbeRepo) (any, error) {
+			fn(ctx, r)
+			return nil, nil
+		}}
+	}
+
+	layout := builtin("layout", CostCheap, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeKind(r.Repo, r.Status)
+		r.Status.commonDir = commonGitDir(r.Path)
+		if isWorking(r.Status) {
+			a.analyzeLayout(r.Path, r.Status)
+		}
+	})
+	branch := builtin("branch", CostCheap, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeBranch(r.Repo, r.Status)
+		if isWorking(r.Status) {
+			a.analyzeDetached(ctx, r.Repo, r.Path, r.Status)
+		}
+	}, "layout")
+	worktree := builtin("worktree_status", CostModerate, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeWorktree(ctx, r.Path, r.Status)
+		a.analyzeOperations(r.Path, r.Status)
+	}, "layout", "branch")
+	worktree.when = isWorking
+	bare := builtin("bare", CostModerate, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeBare(r.Repo, r.Path, r.Status)
+	}, "layout")
+	bare.when = isBare
+	bareSize := builtin("bare_size", CostExpensive, func(ctx context.Context, r *ProbeRepo) {
+		if r.Status.Bare != nil {
+			r.Status.Bare.SizeBytes = dirSize(r.Path)
+		}
+	}, "bare")
+	bareSize.when = isBare
+	lastCommit := builtin("last_commit", CostCheap, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeLastCommit(r.Repo, r.Status)
+		r.Status.IsGhost = time.Since(r.Status.LastCommitTime) > a.ghostThreshold
+	})
+	identity := builtin("identity", CostCheap, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeIdentity(r.Repo, r.Status)
+	})
+	rootCommit := builtin("root_commit", CostExpensive, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeRootCommit(ctx, r.Repo, r.Path, r.Status)
+	})
+	remote := builtin("remote_status", CostModerate, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeRemoteStatus(ctx, r.Repo, r.Path, r.Status)
+	}, "layout")
+	remote.when = isWorking
+	stashes := builtin("stashes", CostCheap, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeStashes(ctx, r.Repo, r.Path, r.Status)
+	}, "layout")
+	stashes.when = isWorking
+	releases := builtin("releases", CostModerate, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeReleases(ctx, r.Repo, r.Path, r.Status)
+	})
+	releases.off = !a.releases
+	branches := builtin("branches", CostExpensive, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeBranches(ctx, r.Repo, r.Path, r.Status)
+	}, "layout")
+	branches.when = isWorking
+	branches.off = !a.branches
+	recent := builtin("recent_commits", CostModerate, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeRecentCommits(ctx, r.Repo, r.authors, r.Status)
+	})
+	recent.off = !a.detailMode
+	lines := builtin("lines_changed", CostExpensive, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeLinesChanged(ctx, r.Path, r.authors, r.Status)
+	})
+	lines.off = !a.detailMode
+	daily := builtin("daily_activity", CostModerate, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeDailyActivity(ctx, r.Repo, r.Path, r.authors, r.Status)
+	})
+	heatmap := builtin("heatmap", CostExpensive, func(ctx context.Context, r *ProbeRepo) {
+		a.analyzeHeatmap(ctx, r.Repo, r.Path, r.authors, r.Status)
+	})
+	heatmap.off = !a.heatmap
+
+	return []Probe{layout, branch, worktree, bare, bareSize, lastCommit, identity, rootCommit, remote, stashes,
+		releases, branches, recent, lines, daily, heatmap}
+}
+
+// reservedSpans are the spans pulse records around probes. Each probe runs in
+// a span of its own name, so a probe may not take one of these, or --time
+// would count it as part of discovery, fetching or the analysis as a whole.
+var reservedSpans = map[string]bool{
+	"find_repos": true,
+	"fetch":      true,
+	"process":    true,
+	"analyze":    true,
+	"plain_open": true,
+}
+
+// planProbes checks the registered probes, applies the enable and disable
+// lists and orders what is left so every probe runs after its dependencies,
+// keeping registration order otherwise. Enabling a probe, or registering one
+// outside the built-in set, enables what it depends on; disabling one
+// disables everything that depends on it.
+func planProbes(probes []Probe, enable, disable []string) ([]Probe, []ProbeInfo, error) {
+	byName := make(map[string]Probe, len(probes))
+	for _, p := range probes {
+		name := p.Name()
+		if name == "" {
+			return nil, nil, errors.New("probe with no name")
+		}
+		if reservedSpans[name] {
+			return nil, nil, fmt.Errorf("probe name %q is reserved", name)
+		}
+		if byName[name] != nil {
+			return nil, nil, fmt.Errorf("probe %q registered twice", name)
+		}
+		byName[name] = p
+	}
+	for _, p := range probes {
+		for _, dep := range p.Deps() {
+			if byName[dep] == nil {
+				return nil, nil, fmt.Errorf("probe %q depends on unknown probe %q", p.Name(), dep)
+			}
+		}
+	}
+
+	ordered, err := sortProbes(probes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enabled := make(map[string]bool, len(probes))
+	for _, p := range probes {
+		fp, ok := p.(*funcProbe)
+		enabled[p.Name()] = !ok || !fp.off
+	}
+	var turnOn func(name string)
+	turnOn = func(name string) {
+		enabled[name] = true
+		for _, dep := range byName[name].Deps() {
+			turnOn(dep)
+		}
+	}
+	// A probe registered through ScanConfig.Probes asks for its
+	// dependencies just as --probe does.
+	for _, p := range probes {
+		if fp, ok := p.(*funcProbe); !ok || !fp.builtIn {
+			turnOn(p.Name())
+		}
+	}
+	for _, name := range enable {
+		if byName[name] == nil {
+			return nil, nil, unknownProbe(name, probes)
+		}
+		turnOn(name)
+	}
+	for _, name := range disable {
+		if byName[name] == nil {
+			return nil, nil, unknownProbe(name, probes)
+		}
+		enabled[name] = false
+	}
+
+	var plan []Probe
+	var infos []ProbeInfo
+	for _, p := range ordered {
+		for _, dep := range p.Deps() {
+			if !enabled[dep] {
+				enabled[p.Name()] = false
+			}
+		}
+		if enabled[p.Name()] {
+			plan = append(plan, p)
+		}
+		fp, _ := p.(*funcProbe)
+		infos = append(infos, ProbeInfo{
+			Name:    p.Name(),
+			Deps:    p.Deps(),
+			Cost:    p.Cost(),
+			Enabled: enabled[p.Name()],
+			BuiltIn: fp != nil && fp.builtIn,
+		})
+	}
+	return plan, infos, nil
+}
+
+// sortProbes repeatedly takes the first probe, in registration order, whose
+// dependencies have all been placed.
+func sortProbes(probes []Probe) ([]Probe, error) {
+	placed := make(map[string]bool, len(probes))
+	ordered := make([]Probe, 0, len(probes))
+	for len(ordered) < len(probes) {
+		progress := false
+		for _, p := range probes {
+			if placed[p.Name()] {
+				continue
+			}
+			ready := true
+			for _, dep := range p.Deps() {
+				if !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[p.Name()] = true
+				ordered = append(ordered, p)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			var stuck []string
+			for _, p := range probes {
+				if !placed[p.Name()] {
+					stuck = append(stuck, p.Name())
+				}
+			}
+			return nil, fmt.Errorf("probe dependency cycle among %s", strings.Join(stuck, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+func unknownProbe(name string, probes []Probe) error {
+	names := make([]string, len(probes))
+	for i, p := range probes {
+		names[i] = p.Name()
+	}
+	return fmt.Errorf("unknown probe %q (have %s)", name, strings.Join(names, ", "))
+}
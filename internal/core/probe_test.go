@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func noop(context.Context, *ProbeRepo) (any, error) { return nil, nil }
+
+func probeNames(probes []Probe) []string {
+	names := make([]string, len(probes))
+	for i, p := range probes {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+func TestPlanProbes(t *testing.T) {
+	probes := []Probe{
+		NewProbe("c", CostCheap, noop, "b"),
+		NewProbe("a", CostCheap, noop),
+		NewProbe("b", CostCheap, noop, "a"),
+		NewProbe("d", CostCheap, noop),
+	}
+
+	plan, infos, err := planProbes(probes, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := probeNames(plan), []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if len(infos) != 4 || !infos[0].Enabled || infos[0].BuiltIn {
+		t.Errorf("infos = %+v", infos)
+	}
+
+	plan, _, err = planProbes(probes, nil, []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := probeNames(plan), []string{"d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("disabling a: plan = %v, want %v", got, want)
+	}
+
+	off := &funcProbe{name: "slow", cost: CostExpensive, deps: []string{"e"}, run: noop, off: true}
+	offDep := &funcProbe{name: "e", cost: CostCheap, run: noop, off: true}
+	plan, _, err = planProbes([]Probe{offDep, off}, []string{"slow"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := probeNames(plan), []string{"e", "slow"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("enabling slow: plan = %v, want %v", got, want)
+	}
+
+	heavy := &funcProbe{name: "heavy", cost: CostExpensive, run: noop, off: true, builtIn: true}
+	plan, _, err = planProbes([]Probe{heavy, NewProbe("needs-heavy", CostCheap, noop, "heavy")}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := probeNames(plan), []string{"heavy", "needs-heavy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("custom probe on an off built-in: plan = %v, want %v", got, want)
+	}
+
+	for name, tc := range map[string]struct {
+		probes          []Probe
+		enable, disable []string
+		wantErrContains string
+	}{
+		"duplicate":   {probes: []Probe{NewProbe("a", CostCheap, noop), NewProbe("a", CostCheap, noop)}, wantErrContains: "twice"},
+		"unnamed":     {probes: []Probe{NewProbe("", CostCheap, noop)}, wantErrContains: "no name"},
+		"reserved":    {probes: []Probe{NewProbe("fetch", CostCheap, noop)}, wantErrContains: `"fetch" is reserved`},
+		"unknown dep": {probes: []Probe{NewProbe("a", CostCheap, noop, "z")}, wantErrContains: `unknown probe "z"`},
+		"cycle":       {probes: []Probe{NewProbe("a", CostCheap, noop, "b"), NewProbe("b", CostCheap, noop, "a")}, wantErrContains: "cycle"},
+		"unknown":     {probes: probes, disable: []string{"z"}, wantErrContains: `unknown probe "z"`},
+	} {
+		_, _, err := planProbes(tc.probes, tc.enable, tc.disable)
+		if err == nil || !strings.Contains(err.Error(), tc.wantErrContains) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.wantErrContains)
+		}
+	}
+}
+
+func TestAnalyzeRunsCustomProbes(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	runGit(t, root, "init", "-q", repoPath)
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "base")
+
+	var sawBranch string
+	analyzer := NewAnalyzer(ScanConfig{
+		Probes: []Probe{
+			NewProbe("owner", CostCheap, func(ctx context.Context, r *ProbeRepo) (any, error) {
+				sawBranch = r.Status.Branch
+				return map[string]string{"team": "core"}, nil
+			}, "branch"),
+			NewProbe("broken", CostCheap, func(context.Context, *ProbeRepo) (any, error) {
+				return nil, errors.New("no CODEOWNERS")
+			}),
+		},
+		DisableProbes: []string{"daily_activity"},
+	})
+
+	status, err := analyzer.Analyze(context.Background(), repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sawBranch == "" || sawBranch == "unknown" {
+		t.Errorf("owner probe ran before branch: saw %q", sawBranch)
+	}
+	if got := status.Extra["owner"]; !reflect.DeepEqual(got, map[string]string{"team": "core"}) {
+		t.Errorf("Extra[owner] = %v", got)
+	}
+	if _, ok := status.Extra["broken"]; ok {
+		t.Error("failed probe left a result in Extra")
+	}
+	if got := status.ProbeErrors["broken"]; got != "no CODEOWNERS" {
+		t.Errorf("ProbeErrors[broken] = %q", got)
+	}
+	if status.DailyActivity != nil {
+		t.Errorf("disabled daily_activity still ran: %v", status.DailyActivity)
+	}
+
+	status, err = NewAnalyzer(ScanConfig{DisableProbes: []string{"last_commit"}}).Analyze(context.Background(), repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.IsGhost {
+		t.Error("repo flagged as a ghost without its last commit")
+	}
+
+	if _, err := NewAnalyzer(ScanConfig{EnableProbes: []string{"nope"}}).Analyze(context.Background(), repoPath); err == nil {
+		t.Error("unknown probe name did not fail the analysis")
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrNotRepository = errors.New("not a git repository")
+
+func DefaultRegistryFile() string {
+	ignore := DefaultIgnoreFile()
+	if ignore == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(ignore), "repos")
+}
+
+// Registry is the list of explicitly tracked repos, stored one absolute path
+// per line so it can also be edited by hand.
+type Registry struct {
+	path  string
+	repos []string
+}
+
+func LoadRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r.repos = append(r.repos, line)
+	}
+	return r, sc.Err()
+}
+
+func (r *Registry) Path() string {
+	return r.path
+}
+
+func (r *Registry) Repos() []string {
+	return append([]string(nil), r.repos...)
+}
+
+func (r *Registry) Contains(path string) bool {
+	path = absPath(path)
+	for _, repo := range r.repos {
+		if repo == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Registry) Add(path string) (string, error) {
+	path = absPath(path)
+	if !IsRepository(path) {
+		return path, fmt.Errorf("%s: %w", path, ErrNotRepository)
+	}
+	if r.Contains(path) {
+		return path, nil
+	}
+	r.repos = append(r.repos, path)
+	sort.Strings(r.repos)
+	return path, nil
+}
+
+func (r *Registry) Remove(path string) (string, bool) {
+	path = absPath(path)
+	for i, repo := range r.repos {
+		if repo == path {
+			r.repos = append(r.repos[:i], r.repos[i+1:]...)
+			return path, true
+		}
+	}
+	return path, false
+}
+
+func (r *Registry) Save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, repo := range r.repos {
+		b.WriteString(repo)
+		b.WriteByte('\n')
+	}
+	return os.WriteFile(r.path, []byte(b.String()), 0o644)
+}
+
+func IsRepository(path string) bool {
+	if _, ok := resolveGitDir(path); ok {
+		return true
+	}
+	return isBareRepo(path)
+}
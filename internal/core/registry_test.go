@@ -0,0 +1,100 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a/.git", "b/.git", "gone/.git", "stale/.git", "plain"} {
+		if err := os.MkdirAll(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(root, name) }
+	file := filepath.Join(root, "config", "repos")
+
+	registry, err := LoadRegistry(file)
+	if err != nil || len(registry.Repos()) != 0 {
+		t.Fatalf("missing registry file: repos = %v, err = %v", registry.Repos(), err)
+	}
+
+	steps := []struct {
+		op      string
+		name    string
+		wantErr error
+		changed bool
+	}{
+		{"add", "b", nil, true},
+		{"add", "a", nil, true},
+		{"add", "a", nil, false},
+		{"add", "plain", ErrNotRepository, false},
+		{"add", "missing", ErrNotRepository, false},
+		{"add", "gone", nil, true},
+		{"add", "stale", nil, true},
+		{"remove", "b", nil, true},
+		{"remove", "b", nil, false},
+		{"add", "b/", nil, true},
+	}
+	for _, step := range steps {
+		before := len(registry.Repos())
+		var got string
+		var err error
+		if step.op == "add" {
+			got, err = registry.Add(path(step.name))
+		} else {
+			got, _ = registry.Remove(path(step.name))
+		}
+		if !errors.Is(err, step.wantErr) {
+			t.Errorf("%s %s: err = %v, want %v", step.op, step.name, err, step.wantErr)
+		}
+		if got != filepath.Clean(path(step.name)) {
+			t.Errorf("%s %s: path = %q", step.op, step.name, got)
+		}
+		if changed := len(registry.Repos()) != before; changed != step.changed {
+			t.Errorf("%s %s: changed = %v, want %v", step.op, step.name, changed, step.changed)
+		}
+	}
+	want := []string{path("a"), path("b"), path("gone"), path("stale")}
+	if got := registry.Repos(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("repos = %v, want %v", got, want)
+	}
+
+	if err := registry.Save(); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(file)
+	if err := os.WriteFile(file, append([]byte("# edited by hand\n\n"), raw...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadRegistry(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Repos(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded = %v, want %v", got, want)
+	}
+
+	os.RemoveAll(path("gone"))
+	os.RemoveAll(path("stale/.git"))
+	s := NewScanner(ScanConfig{RegistryFile: file})
+	var emitted []string
+	w := s.walkRegistry(func(p string) { emitted = append(emitted, p) })
+	if w.err != nil {
+		t.Fatal(w.err)
+	}
+	if want := []string{path("a"), path("b")}; !reflect.DeepEqual(w.repos, want) || !reflect.DeepEqual(emitted, want) {
+		t.Errorf("repos = %v, emitted = %v, want %v", w.repos, emitted, want)
+	}
+	missing := []ScanError{
+		{Path: path("gone"), Message: "registered repository not found", Kind: ErrorMissing},
+		{Path: path("stale"), Message: "registered path is no longer a git repository", Kind: ErrorMissing},
+	}
+	if !reflect.DeepEqual(w.missing, missing) {
+		t.Errorf("missing = %+v, want %+v", w.missing, missing)
+	}
+}
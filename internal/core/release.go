@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+const maxReleaseSubjects = 10
+
+var semverTag = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+type semver struct {
+	parts      [3]int
+	prerelease string
+}
+
+func parseSemver(tag string) (semver, bool) {
+	m := semverTag.FindStringSubmatch(tag)
+	if m == nil {
+		return semver{}, false
+	}
+	var v semver
+	for i := range v.parts {
+		v.parts[i], _ = strconv.Atoi(m[i+1])
+	}
+	v.prerelease = m[4]
+	return v, true
+}
+
+// less orders versions by major, minor and patch, with a prerelease sorting
+// before the release it leads up to. Prereleases compare as plain strings.
+func (v semver) less(o semver) bool {
+	for i := range v.parts {
+		if v.parts[i] != o.parts[i] {
+			return v.parts[i] < o.parts[i]
+		}
+	}
+	switch {
+	case v.prerelease == o.prerelease:
+		return false
+	case v.prerelease == "":
+		return false
+	case o.prerelease == "":
+		return true
+	}
+	return v.prerelease < o.prerelease
+}
+
+func (a *Analyzer) analyzeReleases(ctx context.Context, repo *git.Repository, repoPath string, status *RepoStatus) {
+	head, err := repo.Head()
+	if err != nil {
+		return
+	}
+	status.Release = a.latestRelease(ctx, repoPath, "HEAD")
+
+	if defaultRef, ok := defaultBranch(repo); ok {
+		status.DefaultBranch = defaultRef.Short()
+		ref, err := repo.Reference(defaultRef, true)
+		if err == nil && ref.Hash() != head.Hash() {
+			status.DefaultRelease = a.latestRelease(ctx, repoPath, defaultRef.String())
+		}
+	}
+	status.NoReleases = status.Release == nil && status.DefaultRelease == nil
+}
+
+// latestRelease finds the highest semver tag merged into rev, which is not
+// always the nearest one when maintenance branches are tagged too.
+func (a *Analyzer) latestRelease(ctx context.Context, repoPath, rev string) *ReleaseInfo {
+	out, err := gitCommand(ctx, "-C", repoPath, "for-each-ref", "--merged", rev,
+		"--format=%(refname:short)%00%(creatordate:unix)", "refs/tags").Output()
+	if err != nil {
+		return nil
+	}
+
+	var best *ReleaseInfo
+	var bestVersion semver
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name, date, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		v, ok := parseSemver(name)
+		if !ok || (best != nil && !bestVersion.less(v)) {
+			continue
+		}
+		ts, _ := strconv.ParseInt(date, 10, 64)
+		best = &ReleaseInfo{Tag: name, Date: time.Unix(ts, 0), From: rev}
+		bestVersion = v
+	}
+	if best == nil {
+		return nil
+	}
+
+	out, err = gitCommand(ctx, "-C", repoPath, "log", "--format=%ct%x00%s",
+		"refs/tags/"+best.Tag+".."+rev).Output()
+	if err != nil {
+		return best
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		ts, subject, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		best.CommitsSince++
+		sec, _ := strconv.ParseInt(ts, 10, 64)
+		best.OldestUnreleased = time.Unix(sec, 0)
+		if a.detailMode && len(best.Subjects) < maxReleaseSubjects {
+			best.Subjects = append(best.Subjects, subject)
+		}
+	}
+	return best
+}
+
+// unreleasedSince is how long the oldest unreleased commit on the default
+// branch (or HEAD, if it is the default branch) has been waiting.
+func unreleasedSince(status RepoStatus) (time.Duration, bool) {
+	release := status.DefaultRelease
+	if release == nil {
+		release = status.Release
+	}
+	if release == nil || release.CommitsSince == 0 {
+		return 0, false
+	}
+	return time.Since(release.OldestUnreleased), true
+}
+
+func filterUnreleased(statuses []RepoStatus, olderThan time.Duration) []RepoStatus {
+	var kept []RepoStatus
+	for _, st := range statuses {
+		if age, ok := unreleasedSince(st); ok && age > olderThan {
+			kept = append(kept, st)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSemverLess(t *testing.T) {
+	ordered := []string{"v0.9.9", "v1.0.0-rc.1", "v1.0.0", "1.0.1", "v1.10.0", "v2.0.0+build.5"}
+	for i := 1; i < len(ordered); i++ {
+		a, okA := parseSemver(ordered[i-1])
+		b, okB := parseSemver(ordered[i])
+		if !okA || !okB {
+			t.Fatalf("failed to parse %q or %q", ordered[i-1], ordered[i])
+		}
+		if !a.less(b) || b.less(a) {
+			t.Errorf("want %s < %s", ordered[i-1], ordered[i])
+		}
+	}
+	for _, tag := range []string{"latest", "v1.2", "release-1.2.3"} {
+		if _, ok := parseSemver(tag); ok {
+			t.Errorf("%q parsed as semver", tag)
+		}
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	requireGit(t)
+	repoPath := t.TempDir()
+	runGit(t, repoPath, "init", "-q", "-b", "main")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "one")
+	runGit(t, repoPath, "tag", "v1.10.0")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "two")
+	runGit(t, repoPath, "tag", "v1.9.1")
+	runGit(t, repoPath, "tag", "nightly")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "three")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "four")
+
+	release := NewAnalyzer(ScanConfig{DetailMode: true}).latestRelease(context.Background(), repoPath, "HEAD")
+	if release == nil {
+		t.Fatal("no release found")
+	}
+	if release.Tag != "v1.10.0" || release.CommitsSince != 3 || len(release.Subjects) != 3 || release.Subjects[0] != "four" {
+		t.Errorf("release = %+v", release)
+	}
+
+	status := RepoStatus{Release: release}
+	if _, ok := unreleasedSince(status); !ok {
+		t.Error("unreleased commits not reported")
+	}
+	if kept := filterUnreleased([]RepoStatus{status}, time.Hour); len(kept) != 0 {
+		t.Errorf("fresh unreleased commits kept by a 1h filter")
+	}
+
+	untagged := t.TempDir()
+	runGit(t, untagged, "init", "-q", "-b", "main")
+	runGit(t, untagged, "commit", "-q", "--allow-empty", "-m", "one")
+	tests := []struct {
+		config ScanConfig
+		want   bool
+	}{
+		{ScanConfig{}, false},
+		{ScanConfig{Releases: true}, true},
+		{ScanConfig{UnreleasedOlderThan: time.Hour}, true},
+	}
+	for _, tt := range tests {
+		st, err := NewAnalyzer(tt.config).Analyze(context.Background(), untagged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.NoReleases != tt.want {
+			t.Errorf("releases=%v, older than %s: no releases = %v, want %v",
+				tt.config.Releases, tt.config.UnreleasedOlderThan, st.NoReleases, tt.want)
+		}
+		if kept := filterUnreleased([]RepoStatus{*st}, time.Hour); len(kept) != 0 {
+			t.Error("repo with no releases kept by the filter")
+		}
+	}
+}
+
+func TestScanFiltersUnreleasedAfterTotals(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	fresh := filepath.Join(root, "fresh")
+	runGit(t, root, "init", "-q", "-b", "main", fresh)
+	runGit(t, fresh, "commit", "-q", "--allow-empty", "-m", "untagged")
+
+	month := time.Now().AddDate(0, 0, -30).Format(time.RFC3339)
+	t.Setenv("GIT_AUTHOR_DATE", month)
+	t.Setenv("GIT_COMMITTER_DATE", month)
+	overdue := filepath.Join(root, "overdue")
+	runGit(t, root, "init", "-q", "-b", "main", overdue)
+	runGit(t, overdue, "commit", "-q", "--allow-empty", "-m", "one")
+	runGit(t, overdue, "tag", "v1.0.0")
+	runGit(t, overdue, "commit", "-q", "--allow-empty", "-m", "two")
+
+	result, err := NewScanner(ScanConfig{
+		RootPath:            root,
+		IgnoreFile:          filepath.Join(root, "none"),
+		UnreleasedOlderThan: 7 * 24 * time.Hour,
+	}).Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Repos) != 1 || result.Repos[0].Path != overdue {
+		t.Errorf("listed %d repos, want only %s", len(result.Repos), overdue)
+	}
+	if result.TotalRepos != 2 || result.Roots[0].TotalRepos != 2 || result.Activity.Today != 1 {
+		t.Errorf("total = %d, root total = %d, today = %d; want the filter to leave totals alone",
+			result.TotalRepos, result.Roots[0].TotalRepos, result.Activity.Today)
+	}
+}
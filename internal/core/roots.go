@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const rootCacheVersion = 1
+
+// rootCache remembers each repository's root commits along with the HEAD they
+// were found from. Finding roots walks the whole history, so it is done once
+// per repository; when HEAD has moved forward only the new commits are
+// walked, and anything else starts over.
+type rootCache struct {
+	path string
+
+	mu    sync.Mutex
+	repos map[string]cachedRoots
+	dirty bool
+}
+
+type rootCacheFile struct {
+	Version int                    `json:"version"`
+	Repos   map[string]cachedRoots `json:"repos"`
+}
+
+type cachedRoots struct {
+	Head  string   `json:"head"`
+	Roots []string `json:"roots"`
+}
+
+// rootCachePath keeps the root cache next to the discovery cache, so
+// --cache-file and --no-cache cover both.
+func rootCachePath(discoveryCache string) string {
+	if discoveryCache == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(discoveryCache), "roots.json")
+}
+
+func loadRootCache(path string, rescan bool) *rootCache {
+	c := &rootCache{path: path, repos: make(map[string]cachedRoots)}
+	if path == "" || rescan {
+		return c
+	}
+	var data rootCacheFile
+	if raw, err := os.ReadFile(path); err == nil && json.Unmarshal(raw, &data) == nil &&
+		data.Version == rootCacheVersion && data.Repos != nil {
+		c.repos = data.Repos
+	}
+	return c
+}
+
+// rootCommit returns the lowest root commit reachable from head in the
+// repository identified by key. A nil cache always walks the full history.
+func (c *rootCache) rootCommit(ctx context.Context, repoPath, key, head string) string {
+	var cached cachedRoots
+	var ok bool
+	if c != nil {
+		c.mu.Lock()
+		cached, ok = c.repos[key]
+		c.mu.Unlock()
+	}
+
+	var roots []string
+	var err error
+	switch {
+	case ok && cached.Head == head:
+		roots = cached.Roots
+	case ok && isAncestor(ctx, repoPath, cached.Head, head):
+		roots, err = listRoots(ctx, repoPath, head, "^"+cached.Head)
+		roots = append(roots, cached.Roots...)
+	default:
+		roots, err = listRoots(ctx, repoPath, head)
+	}
+	if err != nil || len(roots) == 0 {
+		return ""
+	}
+
+	if c != nil && (!ok || cached.Head != head) {
+		c.mu.Lock()
+		c.repos[key] = cachedRoots{Head: head, Roots: roots}
+		c.dirty = true
+		c.mu.Unlock()
+	}
+
+	lowest := roots[0]
+	for _, root := range roots[1:] {
+		lowest = min(lowest, root)
+	}
+	return lowest
+}
+
+func listRoots(ctx context.Context, repoPath string, revs ...string) ([]string, error) {
+	args := append([]string{"-C", repoPath, "rev-list", "--max-parents=0"}, revs...)
+	out, err := gitCommand(ctx, args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+func isAncestor(ctx context.Context, repoPath, ancestor, head string) bool {
+	return gitCommand(ctx, "-C", repoPath, "merge-base", "--is-ancestor", ancestor, head).Run() == nil
+}
+
+// save writes the cache if anything changed, dropping repositories that no
+// longer exist.
+func (c *rootCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+
+	for key := range c.repos {
+		if !pathExists(key) {
+			delete(c.repos, key)
+		}
+	}
+	raw, err := json.Marshal(rootCacheFile{Version: rootCacheVersion, Repos: c.repos})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
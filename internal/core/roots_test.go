@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRootCache(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	repoPath := filepath.Join(root, "repo")
+	runGit(t, root, "init", "-q", "-b", "main", repoPath)
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "base")
+	first := strings.TrimSpace(runGit(t, repoPath, "rev-parse", "HEAD"))
+	head := func() string { return strings.TrimSpace(runGit(t, repoPath, "rev-parse", "HEAD")) }
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "roots.json")
+	key := commonGitDir(repoPath)
+	cache := loadRootCache(path, false)
+	if got := cache.rootCommit(ctx, repoPath, key, head()); got != first {
+		t.Fatalf("root = %q, want %q", got, first)
+	}
+
+	// A second root merged in since the cached HEAD is found by walking only
+	// the new commits.
+	runGit(t, repoPath, "checkout", "-q", "--orphan", "other")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "other root")
+	other := head()
+	runGit(t, repoPath, "checkout", "-q", "main")
+	runGit(t, repoPath, "merge", "-q", "--allow-unrelated-histories", "-m", "join", "other")
+	if got, want := cache.rootCommit(ctx, repoPath, key, head()), min(first, other); got != want {
+		t.Errorf("after merge: root = %q, want %q", got, want)
+	}
+	if roots := cache.repos[key].Roots; len(roots) != 2 {
+		t.Errorf("cached roots = %v, want both", roots)
+	}
+
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := loadRootCache(path, false)
+	if entry := reloaded.repos[key]; entry.Head != head() || len(entry.Roots) != 2 {
+		t.Errorf("reloaded entry = %+v", entry)
+	}
+	if loadRootCache(path, true).repos[key].Head != "" {
+		t.Error("rescan reused the cached roots")
+	}
+
+	var none *rootCache
+	if got := none.rootCommit(ctx, repoPath, key, first); got != first {
+		t.Errorf("uncached root = %q, want %q", got, first)
+	}
+}
@@ -2,14 +2,16 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/guidefari/pulse/internal/tracing"
-	"github.com/karrick/godirwalk"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Scanner struct {
@@ -26,90 +28,549 @@ func NewScanner(config ScanConfig) *Scanner {
 	if config.GhostThreshold <= 0 {
 		config.GhostThreshold = DefaultGhostThreshold
 	}
+	if config.ActivityDays <= 0 {
+		config.ActivityDays = DefaultActivityDays
+	}
+	if config.IgnoreFile == "" {
+		config.IgnoreFile = DefaultIgnoreFile()
+	}
+	if len(config.Roots) == 0 {
+		config.Roots = []ScanRoot{{Path: config.RootPath, MaxDepth: config.MaxDepth}}
+	}
+	config.Roots = uniqueRoots(config.Roots, config.MaxDepth)
 	return &Scanner{config: config}
 }
 
+// uniqueRoots fills in default depths and merges roots naming the same
+// directory, keeping the first spelling and the deepest depth asked for.
+func uniqueRoots(roots []ScanRoot, defaultDepth int) []ScanRoot {
+	var unique []ScanRoot
+	index := make(map[string]int, len(roots))
+	for _, root := range roots {
+		if root.MaxDepth <= 0 {
+			root.MaxDepth = defaultDepth
+		}
+		key := absPath(root.Path)
+		if i, ok := index[key]; ok {
+			unique[i].MaxDepth = max(unique[i].MaxDepth, root.MaxDepth)
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, root)
+	}
+	return unique
+}
+
 func (s *Scanner) Scan(ctx context.Context) (*ScanResult, error) {
 	start := time.Now()
+	if s.config.ScanTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.ScanTimeout)
+		defer cancel()
+	}
 
-	ctx, findSpan := tracing.Tracer().Start(ctx, "find_repos")
-	found, err := s.findRepos()
-	findSpan.End()
-	if err != nil {
+	analyzer := NewAnalyzer(s.config)
+	if _, err := analyzer.Probes(); err != nil {
 		return nil, err
 	}
-
-	analyzer := NewAnalyzer(s.config.DetailMode, s.config.Fetch, s.config.GhostThreshold)
+	analyzer.roots = loadRootCache(rootCachePath(s.config.CacheFile), s.config.Rescan)
 	pool := NewPool(s.config.WorkerCount)
 
+	// When discovery fails the scan does, so analysis stops as soon as it does.
+	poolCtx, cancelPool := context.WithCancel(ctx)
+	defer cancelPool()
+
+	paths := make(chan string, discoveryBuffer)
+	var found *findResult
+	var findErr error
+
+	_, findSpan := tracing.Tracer().Start(ctx, "find_repos")
+	go func() {
+		defer close(paths)
+		found, findErr = s.findRepos(ctx, func(path string) { paths <- path })
+		if findErr != nil {
+			cancelPool()
+		}
+		if found != nil {
+			findSpan.SetAttributes(
+				attribute.Bool("cache.enabled", s.config.CacheFile != ""),
+				attribute.Int("cache.hits", found.cache.hits),
+				attribute.Int("cache.misses", found.cache.misses),
+			)
+		}
+		findSpan.End()
+	}()
+
+	var fetcher *fetchStage
+	analyzePaths := (<-chan string)(paths)
+	if s.config.Fetch {
+		fetcher = newFetchStage(s.config)
+		analyzePaths = fetcher.stream(poolCtx, paths)
+	}
+
 	_, processSpan := tracing.Tracer().Start(ctx, "process")
-	statuses, scanErrors := pool.Process(ctx, found.repos, analyzer)
+	statuses, scanErrors, unfinished := pool.Stream(poolCtx, analyzePaths, analyzer)
 	processSpan.End()
 
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	var authorErrors []ScanError
+	for i := range statuses {
+		if err := statuses[i].authorsErr; err != nil {
+			authorErrors = append(authorErrors, ScanError{Path: statuses[i].Path, Message: err.Error(), Kind: ErrorAuthors})
+		}
+		statuses[i].Root = found.repoRoots[repoKey(statuses[i].Path)]
+		if fetcher != nil {
+			statuses[i].Fetch = fetcher.result(statuses[i].Path)
+		}
+	}
+	scanErrors = append(append(found.errors, scanErrors...), authorErrors...)
+	if ctx.Err() == nil {
+		if err := analyzer.roots.save(); err != nil {
+			scanErrors = append(scanErrors, ScanError{Path: analyzer.roots.path, Message: "save root commit cache: " + err.Error(), Kind: ErrorCache})
+		}
+	}
+
 	result := &ScanResult{
 		Repos:        statuses,
 		TotalRepos:   len(statuses),
+		Roots:        summarizeRoots(found.walks, statuses),
 		NonGitPaths:  found.nonGitPaths,
+		NonGitDirs:   found.nonGitDirs,
 		ScanDuration: time.Since(start),
 		Errors:       scanErrors,
+		Ignored:      found.ignored,
+		Unfinished:   unfinished,
+	}
+	if err := ctx.Err(); err != nil {
+		result.Stopped = stopReason(err)
 	}
 
-	if s.config.DetailMode {
-		result.DailyCommits = s.tallyDailyCommits(statuses)
+	result.Duplicates = findDuplicates(statuses)
+	var clones []*DuplicateClone
+	for _, group := range result.Duplicates {
+		for i := range group.Clones {
+			if group.Clones[i].Kind == KindWorking && !group.Clones[i].NoRemote {
+				clones = append(clones, &group.Clones[i])
+			}
+		}
 	}
+	pool.Each(ctx, len(clones), func(i int) {
+		clones[i].LocalOnlyCommits = localOnlyCommits(ctx, clones[i].Path)
+	})
 
+	clock := newDayClock(s.config)
+	result.Timezone = clock.zoneName()
+	result.DayStartHour = clock.startHour
+	result.Today = clock.today().Format(dateLayout)
+	result.Heatmap = mergeHeatmaps(statuses, clock)
+
+	result.Activity = tallyActivity(statuses, clock.today())
+	if result.Activity != nil {
+		result.DailyCommits = make(map[string]int, len(result.Activity.Days))
+		for _, day := range result.Activity.Days {
+			result.DailyCommits[day.Date] = day.Commits
+		}
+	}
+
+	// Only the listing is filtered; root totals, duplicates and activity
+	// above still cover every repo scanned.
+	if s.config.UnreleasedOlderThan > 0 {
+		result.Repos = filterUnreleased(result.Repos, s.config.UnreleasedOlderThan)
+	}
 	return result, nil
 }
 
 type findResult struct {
+	cache       cacheStats
+	errors      []ScanError
+	repos       []string
+	repoRoots   map[string]string
+	walks       []rootWalk
+	nonGitPaths []string
+	nonGitDirs  []NonGitDir
+	ignored     []IgnoredPath
+}
+
+type rootWalk struct {
+	root        ScanRoot
+	registry    bool
 	repos       []string
 	nonGitPaths []string
+	nonGitDirs  []NonGitDir
+	ignored     []IgnoredPath
+	missing     []ScanError
+	err         error
+}
+
+// discoveryBuffer lets the walk run ahead of busy workers so the find_repos
+// span measures the walk rather than back-pressure from analysis.
+const discoveryBuffer = 1024
+
+// findRepos walks every root in parallel. emit, if non-nil, receives each repo
+// the first time any walk reaches it, so analysis can start before the walk ends.
+func (s *Scanner) findRepos(ctx context.Context, emit func(string)) (*findResult, error) {
+	var roots []ScanRoot
+	if !s.config.RegistryOnly {
+		roots = s.config.Roots
+	}
+	walks := make([]rootWalk, len(roots))
+	cache := loadDiscoveryCache(s.config.CacheFile, s.config.Rescan)
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	found := func(path string) {
+		if emit == nil {
+			return
+		}
+		key := repoKey(path)
+		mu.Lock()
+		first := !seen[key]
+		seen[key] = true
+		mu.Unlock()
+		if first {
+			emit(path)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, root := range roots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			walks[i] = s.walkRoot(ctx, root, cache, found)
+		}()
+	}
+
+	var registry []rootWalk
+	if s.config.RegistryFile != "" {
+		registry = append(registry, s.walkRegistry(found))
+	}
+	wg.Wait()
+	walks = append(walks, registry...)
+
+	result, err := mergeWalks(walks)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() == nil {
+		if err := cache.save(roots); err != nil {
+			result.errors = append(result.errors, ScanError{Path: s.config.CacheFile, Message: "save discovery cache: " + err.Error(), Kind: ErrorCache})
+		}
+	}
+	result.cache = cache.stats()
+	return result, nil
+}
+
+// specificity ranks the roots that reached the same repo. Deeper roots win,
+// and the registry only claims repos no walk reached.
+func (w *rootWalk) specificity() int {
+	if w.registry {
+		return -1
+	}
+	return len(absPath(w.root.Path))
+}
+
+// mergeWalks de-duplicates repos reached from overlapping roots, assigning each
+// one to the most specific root that found it. A root that could not be walked
+// is reported in errors and the rest are kept; the scan only fails when none
+// of the directory roots, or nothing at all, could be walked.
+func mergeWalks(walks []rootWalk) (*findResult, error) {
+	found := &findResult{
+		repoRoots: make(map[string]string),
+	}
+
+	owner := make(map[string]int)
+	paths := make(map[string]string)
+	var order []string
+	var firstErr error
+	dirRoots, dirWalked := 0, 0
+	for i, w := range walks {
+		if !w.registry {
+			dirRoots++
+		}
+		if w.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("scan %s: %w", w.root.Path, w.err)
+			}
+			found.errors = append(found.errors, ScanError{Path: w.root.Path, Message: w.err.Error(), Kind: ErrorRoot})
+			continue
+		}
+		if !w.registry {
+			dirWalked++
+		}
+		found.walks = append(found.walks, w)
+		found.errors = append(found.errors, w.missing...)
+		for _, r := range w.repos {
+			key := repoKey(r)
+			if j, ok := owner[key]; ok {
+				if walks[j].specificity() >= w.specificity() {
+					continue
+				}
+			} else {
+				order = append(order, key)
+			}
+			owner[key] = i
+			paths[key] = r
+		}
+	}
+
+	if firstErr != nil && (len(found.walks) == 0 || dirRoots > 0 && dirWalked == 0) {
+		return nil, firstErr
+	}
+
+	for _, key := range order {
+		found.repos = append(found.repos, paths[key])
+		found.repoRoots[key] = walks[owner[key]].root.Path
+	}
+
+	for i := range found.walks {
+		w := &found.walks[i]
+		for _, p := range w.nonGitPaths {
+			found.nonGitPaths = append(found.nonGitPaths, rootRelative(found.walks, w.root, p))
+		}
+		for _, d := range w.nonGitDirs {
+			d.Path = rootRelative(found.walks, w.root, d.Path)
+			found.nonGitDirs = append(found.nonGitDirs, d)
+		}
+		for _, ig := range w.ignored {
+			ig.Path = rootRelative(found.walks, w.root, ig.Path)
+			found.ignored = append(found.ignored, ig)
+		}
+	}
+
+	return found, nil
 }
 
-func (s *Scanner) findRepos() (*findResult, error) {
-	rootDepth := strings.Count(filepath.Clean(s.config.RootPath), string(filepath.Separator))
-	var repos []string
-	skipDirs := map[string]bool{
-		"node_modules": true,
-		".Trash":       true,
-		"vendor":       true,
+func (s *Scanner) walkRoot(ctx context.Context, root ScanRoot, cache *discoveryCache, found func(string)) rootWalk {
+	w := rootWalk{root: root}
+	absRoot := absPath(root.Path)
+	display := func(path string) string {
+		return filepath.Join(root.Path, relPath(absRoot, path))
 	}
+	var absRepos []string
+	added := make(map[string]bool)
+	addRepo := func(path string) {
+		shown := display(path)
+		if s.config.FollowSymlinks {
+			if real, err := filepath.EvalSymlinks(path); err == nil && real != path {
+				shown = real
+			}
+		}
+		if added[shown] {
+			return
+		}
+		added[shown] = true
+		absRepos = append(absRepos, path)
+		w.repos = append(w.repos, shown)
+		found(shown)
+	}
+	rootDepth := strings.Count(absRoot, string(filepath.Separator))
+	depthOf := func(path string) int {
+		return strings.Count(path, string(filepath.Separator)) - rootDepth
+	}
+	withinDepth := func(path string) bool {
+		return depthOf(path) <= root.MaxDepth
+	}
+	ignore := newIgnoreMatcher(absRoot, s.config.IgnoreFile)
+
+	// seen holds the shallowest depth each directory was reached at. A
+	// symlink may reach a directory deeper than its real path does, so a
+	// directory is walked again when reached higher up; a cycle only ever
+	// comes back deeper and ends there.
+	var rootDev uint64
+	seen := make(map[fileID]int)
+
+	var visit func(dir string) error
+	visit = func(dir string) error {
+		if ctx.Err() != nil {
+			return nil
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s: not a directory", dir)
+		}
 
-	err := godirwalk.Walk(s.config.RootPath, &godirwalk.Options{
-		Unsorted: true,
-		Callback: func(path string, de *godirwalk.Dirent) error {
-			if !de.IsDir() {
+		if id, ok := fileIdentity(info); ok {
+			if dir == absRoot {
+				rootDev = id.dev
+			} else if s.config.OneFileSystem && id.dev != rootDev {
 				return nil
 			}
+			if s.config.FollowSymlinks {
+				if depth, ok := seen[id]; ok && depth <= depthOf(dir) {
+					return nil
+				}
+				seen[id] = depthOf(dir)
+			}
+		}
 
-			name := de.Name()
-			if skipDirs[name] {
-				return godirwalk.SkipThis
+		entry, err := cache.lookup(dir, info)
+		if err != nil {
+			return err
+		}
+
+		if entry.Bare && withinDepth(filepath.Join(dir, "HEAD")) {
+			addRepo(dir)
+			return nil
+		}
+		if entry.GitFile && withinDepth(filepath.Join(dir, ".git")) {
+			addRepo(dir)
+		}
+
+		visitChild := func(name string) {
+			child := filepath.Join(dir, name)
+
+			if rule := ignore.match(child, true); rule != nil {
+				if s.config.ReportIgnored {
+					w.ignored = append(w.ignored, IgnoredPath{
+						Path:   relPath(absRoot, child),
+						Rule:   rule.text,
+						Source: rule.source,
+					})
+				}
+				return
 			}
 
-			currentDepth := strings.Count(filepath.Clean(path), string(filepath.Separator)) - rootDepth
-			if currentDepth > s.config.MaxDepth {
-				return godirwalk.SkipThis
+			if !withinDepth(child) {
+				return
 			}
 
 			if name == ".git" {
-				repos = append(repos, filepath.Dir(path))
-				return godirwalk.SkipThis
+				addRepo(dir)
+				return
 			}
 
-			return nil
-		},
-		ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
-			return godirwalk.SkipNode
-		},
-	})
+			visit(child)
+		}
 
-	nonGitPaths := findNonGitSiblings(s.config.RootPath, repos, skipDirs)
+		for _, name := range entry.Dirs {
+			visitChild(name)
+		}
+		if s.config.FollowSymlinks {
+			for _, name := range entry.Links {
+				visitChild(name)
+			}
+		}
+		return nil
+	}
 
-	return &findResult{repos: repos, nonGitPaths: nonGitPaths}, err
+	w.err = visit(absRoot)
+	if ctx.Err() != nil {
+		return w
+	}
+
+	w.nonGitPaths = findNonGitSiblings(absRoot, absRepos, ignore, cache)
+	sort.Slice(w.ignored, func(i, j int) bool { return w.ignored[i].Path < w.ignored[j].Path })
+
+	var nonGitPaths []string
+	for _, p := range w.nonGitPaths {
+		d := cache.classify(filepath.Join(absRoot, p), ignore)
+		if s.config.UntrackedProjects && !d.LikelyProject() {
+			continue
+		}
+		d.Path = p
+		w.nonGitDirs = append(w.nonGitDirs, d)
+		nonGitPaths = append(nonGitPaths, p)
+	}
+	w.nonGitPaths = nonGitPaths
+
+	return w
 }
 
-func findNonGitSiblings(rootPath string, repos []string, skipDirs map[string]bool) []string {
+func (s *Scanner) walkRegistry(found func(string)) rootWalk {
+	w := rootWalk{
+		root:     ScanRoot{Path: s.config.RegistryFile},
+		registry: true,
+	}
+
+	registry, err := LoadRegistry(s.config.RegistryFile)
+	if err != nil {
+		w.err = err
+		return w
+	}
+
+	for _, repo := range registry.Repos() {
+		switch {
+		case IsRepository(repo):
+			w.repos = append(w.repos, repo)
+			found(repo)
+		case pathExists(repo):
+			w.missing = append(w.missing, ScanError{Path: repo, Message: "registered path is no longer a git repository", Kind: ErrorMissing})
+		default:
+			w.missing = append(w.missing, ScanError{Path: repo, Message: "registered repository not found", Kind: ErrorMissing})
+		}
+	}
+	return w
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func rootRelative(walks []rootWalk, root ScanRoot, rel string) string {
+	dirWalks := 0
+	for _, w := range walks {
+		if !w.registry {
+			dirWalks++
+		}
+	}
+	if dirWalks == 1 {
+		return rel
+	}
+	return filepath.Join(root.Path, rel)
+}
+
+func summarizeRoots(walks []rootWalk, statuses []RepoStatus) []RootSummary {
+	summaries := make([]RootSummary, len(walks))
+	index := make(map[string]int, len(walks))
+	for i, w := range walks {
+		summaries[i] = RootSummary{
+			Path:        w.root.Path,
+			Registry:    w.registry,
+			MaxDepth:    w.root.MaxDepth,
+			NonGitPaths: w.nonGitPaths,
+		}
+		index[w.root.Path] = i
+	}
+
+	for _, st := range statuses {
+		i, ok := index[st.Root]
+		if !ok {
+			continue
+		}
+		sum := &summaries[i]
+		sum.TotalRepos++
+		if !st.IsClean {
+			sum.DirtyRepos++
+		}
+		if st.IsGhost {
+			sum.GhostRepos++
+		}
+		if st.AtRisk {
+			sum.AtRiskRepos++
+		}
+		sum.UnpushedCommits += st.UnpushedCommits
+		sum.UnpulledCommits += st.UnpulledCommits
+	}
+
+	kept := summaries[:0]
+	for _, sum := range summaries {
+		if sum.Registry && sum.TotalRepos == 0 {
+			continue
+		}
+		kept = append(kept, sum)
+	}
+	return kept
+}
+
+func findNonGitSiblings(rootPath string, repos []string, ignore *ignoreMatcher, cache *discoveryCache) []string {
 	repoSet := make(map[string]bool, len(repos))
 	parentDirs := make(map[string]bool)
 	for _, r := range repos {
@@ -119,20 +580,12 @@ func findNonGitSiblings(rootPath string, repos []string, skipDirs map[string]boo
 
 	var nonGit []string
 	for parent := range parentDirs {
-		entries, err := os.ReadDir(parent)
-		if err != nil {
-			continue
-		}
-		for _, e := range entries {
-			if !e.IsDir() {
-				continue
-			}
-			name := e.Name()
-			if name == ".git" || skipDirs[name] {
+		for _, name := range cache.subdirs(parent) {
+			if name == ".git" {
 				continue
 			}
 			childPath := filepath.Join(parent, name)
-			if repoSet[childPath] {
+			if repoSet[childPath] || ignore.match(childPath, true) != nil || looksLikeGitDir(childPath) {
 				continue
 			}
 			containsRepo := false
@@ -143,11 +596,7 @@ func findNonGitSiblings(rootPath string, repos []string, skipDirs map[string]boo
 				}
 			}
 			if !containsRepo {
-				rel, err := filepath.Rel(rootPath, childPath)
-				if err != nil {
-					rel = filepath.Base(childPath)
-				}
-				nonGit = append(nonGit, rel)
+				nonGit = append(nonGit, relPath(rootPath, childPath))
 			}
 		}
 	}
@@ -156,18 +605,27 @@ func findNonGitSiblings(rootPath string, repos []string, skipDirs map[string]boo
 	return nonGit
 }
 
-func (s *Scanner) tallyDailyCommits(statuses []RepoStatus) map[string]int {
-	tally := make(map[string]int)
-	today := time.Now().Format("2006-01-02")
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
 
-	for _, status := range statuses {
-		for _, commit := range status.RecentCommits {
-			date := commit.Timestamp.Format("2006-01-02")
-			if date == today {
-				tally[date]++
-			}
-		}
+// repoKey identifies a repo regardless of which root or symlink reached it.
+func repoKey(path string) string {
+	abs := absPath(path)
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real
 	}
+	return abs
+}
 
-	return tally
+func relPath(rootPath, path string) string {
+	rel, err := filepath.Rel(rootPath, path)
+	if err != nil {
+		return filepath.Base(path)
+	}
+	return rel
 }
@@ -27,7 +27,7 @@ func BenchmarkFindRepos(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		s.findRepos()
+		s.findRepos(context.Background(), nil)
 	}
 }
 
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFindReposFollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	real := filepath.Join(root, "src", "real")
+	if err := os.MkdirAll(filepath.Join(real, "proj", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Symlink(real, filepath.Join(root, "src", "current"))
+	os.Symlink("..", filepath.Join(real, "loop"))
+
+	for _, follow := range []bool{false, true} {
+		s := NewScanner(ScanConfig{
+			RootPath:       filepath.Join(root, "src"),
+			MaxDepth:       8,
+			IgnoreFile:     filepath.Join(root, "none"),
+			FollowSymlinks: follow,
+		})
+
+		found, err := s.findRepos(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found.repos) != 1 || found.repos[0] != filepath.Join(real, "proj") {
+			t.Errorf("follow=%v: got %v, want only the canonical %s", follow, found.repos, filepath.Join(real, "proj"))
+		}
+	}
+
+	links := filepath.Join(root, "links")
+	os.Mkdir(links, 0o755)
+	os.Symlink(real, filepath.Join(links, "current"))
+
+	s := NewScanner(ScanConfig{
+		RootPath:       links,
+		IgnoreFile:     filepath.Join(root, "none"),
+		FollowSymlinks: true,
+	})
+	found, _ := s.findRepos(context.Background(), nil)
+	if len(found.repos) != 1 || found.repos[0] != filepath.Join(real, "proj") {
+		t.Errorf("via symlink: got %v, want %s", found.repos, filepath.Join(real, "proj"))
+	}
+
+	// A link walked before the real path reaches the repo too deep; the real
+	// path, one level up, must still be walked.
+	deep := filepath.Join(root, "deep")
+	if err := os.MkdirAll(filepath.Join(deep, "d", "x", "y", ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Mkdir(filepath.Join(deep, "a"), 0o755)
+	os.Symlink("../d", filepath.Join(deep, "a", "link"))
+	for _, follow := range []bool{false, true} {
+		s := NewScanner(ScanConfig{
+			RootPath:       deep,
+			MaxDepth:       4,
+			IgnoreFile:     filepath.Join(root, "none"),
+			FollowSymlinks: follow,
+		})
+		found, err := s.findRepos(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(deep, "d", "x", "y"); len(found.repos) != 1 || found.repos[0] != want {
+			t.Errorf("shallower real path, follow=%v: got %v, want %s", follow, found.repos, want)
+		}
+	}
+}
+
+func TestScanStopsWhenDiscoveryFails(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	repo := filepath.Join(root, "tracked")
+	runGit(t, root, "init", "-q", repo)
+	registry := filepath.Join(root, "repos")
+	if err := os.WriteFile(registry, []byte(repo+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registered repo is handed to analysis while the only directory
+	// root fails; its analysis waits for the scan to give up on it.
+	var mu sync.Mutex
+	var finished bool
+	wait := NewProbe("wait", CostCheap, func(ctx context.Context, _ *ProbeRepo) (any, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			mu.Lock()
+			finished = true
+			mu.Unlock()
+			return nil, nil
+		}
+	})
+	s := NewScanner(ScanConfig{
+		Roots:        []ScanRoot{{Path: filepath.Join(root, "missing")}},
+		RegistryFile: registry,
+		IgnoreFile:   filepath.Join(root, "none"),
+		Probes:       []Probe{wait},
+	})
+	if _, err := s.Scan(context.Background()); err == nil {
+		t.Fatal("scan with no walkable root succeeded")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if finished {
+		t.Error("analysis ran on after discovery failed")
+	}
+}
+
+func TestScanRoots(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	sub := filepath.Join(src, "a", "sub")
+	for _, repo := range []string{filepath.Join(src, "one"), filepath.Join(sub, "two"), filepath.Join(sub, "three")} {
+		runGit(t, root, "init", "-q", repo)
+	}
+	missing := filepath.Join(root, "missing")
+
+	result, err := NewScanner(ScanConfig{
+		Roots: []ScanRoot{
+			{Path: src, MaxDepth: 2},
+			{Path: missing},
+			{Path: sub},
+			{Path: src + string(filepath.Separator), MaxDepth: 4},
+		},
+		IgnoreFile: filepath.Join(root, "none"),
+	}).Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type total struct {
+		path         string
+		depth, repos int
+	}
+	var got []total
+	for _, r := range result.Roots {
+		got = append(got, total{r.Path, r.MaxDepth, r.TotalRepos})
+	}
+	// src is listed once with the deeper depth, and sub claims its own repos.
+	if want := []total{{src, 4, 1}, {sub, 3, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("roots = %+v, want %+v", got, want)
+	}
+	if result.TotalRepos != 3 {
+		t.Errorf("found %d repos, want 3 each reported once", result.TotalRepos)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Path != missing || result.Errors[0].Kind != ErrorRoot {
+		t.Errorf("errors = %+v, want the missing root", result.Errors)
+	}
+
+	if _, err := NewScanner(ScanConfig{RootPath: missing, IgnoreFile: filepath.Join(root, "none")}).Scan(context.Background()); err == nil {
+		t.Error("scan of only a missing root succeeded")
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const stashRef = plumbing.ReferenceName("refs/stash")
+
+// analyzeStashes walks the refs/stash reflog, which is where every stash
+// entry but the newest lives. Files are counted against the stash's base
+// commit, so untracked files saved with -u are not included.
+func (a *Analyzer) analyzeStashes(ctx context.Context, repo *git.Repository, repoPath string, status *RepoStatus) {
+	if _, err := repo.Reference(stashRef, false); err != nil {
+		return
+	}
+
+	out, err := gitCommand(ctx, "-C", repoPath, "-c", "core.quotePath=false", "log", "-g", "--diff-merges=first-parent",
+		"--name-only", "--format=%x01%gd%x00%gs%x00%ct", stashRef.String()).Output()
+	if err != nil {
+		return
+	}
+
+	for _, entry := range strings.Split(string(out), "\x01") {
+		header, files, _ := strings.Cut(entry, "\n")
+		fields := strings.Split(header, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(fields[2], 10, 64)
+		branch, message := parseStashSubject(fields[1])
+		stash := Stash{
+			Ref:     fields[0],
+			Branch:  branch,
+			Message: message,
+			Created: time.Unix(ts, 0),
+			Files:   countLines(files),
+		}
+		stash.Stale = time.Since(stash.Created) > a.ghostThreshold
+		status.Stashes = append(status.Stashes, stash)
+	}
+}
+
+// countLines counts the non-empty lines of --name-only output, one per file.
+func countLines(out string) int {
+	n := 0
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// parseStashSubject splits "WIP on main: abc1234 subject" and
+// "On main: message" into the branch and the message.
+func parseStashSubject(subject string) (string, string) {
+	rest, ok := strings.CutPrefix(subject, "WIP on ")
+	if !ok {
+		rest, ok = strings.CutPrefix(subject, "On ")
+	}
+	if !ok {
+		return "", subject
+	}
+	branch, message, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return "", subject
+	}
+	return branch, message
+}
+
+// atRisk flags work that exists only on this machine and has been left alone:
+// a stash older than the ghost threshold, or a ghost repo that is dirty or has
+// unpushed commits. Commits only a detached HEAD reaches are always at risk.
+func atRisk(status *RepoStatus) bool {
+	if status.Detached != nil && status.Detached.Unreachable > 0 {
+		return true
+	}
+	for _, s := range status.Stashes {
+		if s.Stale {
+			return true
+		}
+	}
+	return status.IsGhost && (!status.IsClean || status.UnpushedCommits > 0)
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeStashes(t *testing.T) {
+	requireGit(t)
+	repoPath := t.TempDir()
+	runGit(t, repoPath, "init", "-q", "-b", "main")
+	runGit(t, repoPath, "commit", "-q", "--allow-empty", "-m", "base")
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		os.WriteFile(filepath.Join(repoPath, name), []byte(name), 0o644)
+	}
+	runGit(t, repoPath, "add", ".")
+	runGit(t, repoPath, "stash", "-q")
+	os.WriteFile(filepath.Join(repoPath, "meeting notes.txt"), []byte("c"), 0o644)
+	runGit(t, repoPath, "add", ".")
+	runGit(t, repoPath, "stash", "push", "-q", "-m", "try the cache")
+
+	repo, err := openRepo(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := &RepoStatus{}
+	NewAnalyzer(ScanConfig{}).analyzeStashes(context.Background(), repo, repoPath, status)
+
+	if len(status.Stashes) != 2 {
+		t.Fatalf("got %d stashes, want 2", len(status.Stashes))
+	}
+	newest, oldest := status.Stashes[0], status.Stashes[1]
+	if newest.Ref != "stash@{0}" || newest.Message != "try the cache" || newest.Branch != "main" || newest.Files != 1 {
+		t.Errorf("newest = %+v", newest)
+	}
+	if oldest.Ref != "stash@{1}" || oldest.Message == "" || oldest.Branch != "main" || oldest.Files != 2 {
+		t.Errorf("oldest = %+v", oldest)
+	}
+	if newest.Stale || atRisk(status) {
+		t.Errorf("fresh stashes reported as at risk")
+	}
+}
@@ -4,28 +4,297 @@ import "time"
 
 const DefaultGhostThreshold = 30 * 24 * time.Hour
 
+const DefaultActivityDays = 7
+
+const (
+	DefaultFetchTimeout = 30 * time.Second
+	DefaultFetchWorkers = 8
+	DefaultRepoTimeout  = 2 * time.Minute
+)
+
 type ScanConfig struct {
-	RootPath       string
-	MaxDepth       int
-	DetailMode     bool
-	Fetch          bool
-	GhostThreshold time.Duration
-	WorkerCount    int
+	RootPath   string
+	MaxDepth   int
+	Roots      []ScanRoot
+	DetailMode bool
+	Fetch      bool
+	// FetchTimeout bounds each repo's fetch; FetchWorkers is how many run at
+	// once, separately from the analysis workers.
+	FetchTimeout      time.Duration
+	FetchWorkers      int
+	GhostThreshold    time.Duration
+	WorkerCount       int
+	IgnoreFile        string
+	ReportIgnored     bool
+	CacheFile         string
+	Rescan            bool
+	RegistryFile      string
+	RegistryOnly      bool
+	FollowSymlinks    bool
+	OneFileSystem     bool
+	UntrackedProjects bool
+	Branches          bool
+	IgnoredFiles      bool
+	Authors           AuthorFilter
+	// ActivityDays is the window for the sparkline and lines changed.
+	ActivityDays int
+	// Heatmap builds a 52-week contribution grid per repo and for the tree.
+	Heatmap bool
+	// LinesExclude holds gitignore-style patterns for files left out of
+	// lines changed, on top of linguist-generated and linguist-vendored.
+	LinesExclude []string
+	// IncludeMerges counts merge commits in lines changed.
+	IncludeMerges bool
+	// RepoTimeout bounds the analysis of each repo and ScanTimeout the whole
+	// scan; zero means no limit. Repos cut short are reported in
+	// ScanResult.Unfinished.
+	RepoTimeout time.Duration
+	ScanTimeout time.Duration
+	// Location is the zone commits are bucketed into days in; nil means local.
+	Location *time.Location
+	// DayStartHour moves the boundary between days from midnight to this
+	// hour, so late-night commits count towards the day before.
+	DayStartHour int
+	// Releases finds each repo's latest semver tag and the commits made since.
+	Releases bool
+	// UnreleasedOlderThan, if set, lists only repos whose oldest unreleased
+	// commit on the default branch is older than this; repos never released
+	// are left out. Totals still cover every repo. It turns on Releases.
+	UnreleasedOlderThan time.Duration
+	// Probes adds analyses to run after the built-in ones, or between them
+	// where their dependencies say so. EnableProbes and DisableProbes turn
+	// probes on and off by name.
+	Probes        []Probe
+	EnableProbes  []string
+	DisableProbes []string
 }
 
+// AuthorFilter limits activity, lines changed, recent commits and the daily
+// tally to the user's own commits. Mine adds the user.name and user.email git
+// resolves in each repo; Aliases adds other names and emails (anything
+// containing @ is treated as an email). Matching is case-insensitive.
+type AuthorFilter struct {
+	Mine    bool
+	Aliases []string
+}
+
+func (f AuthorFilter) Active() bool {
+	return f.Mine || len(f.Aliases) > 0
+}
+
+type ScanRoot struct {
+	Path     string `json:"path"`
+	MaxDepth int    `json:"max_depth"`
+}
+
+type WorktreeKind string
+
+const (
+	WorktreeMain      WorktreeKind = "main"
+	WorktreeLinked    WorktreeKind = "linked"
+	WorktreeSubmodule WorktreeKind = "submodule"
+)
+
+type RepoKind string
+
+const (
+	KindWorking RepoKind = "working"
+	KindBare    RepoKind = "bare"
+	KindMirror  RepoKind = "mirror"
+)
+
 type RepoStatus struct {
-	Name            string        `json:"name"`
-	Path            string        `json:"path"`
-	Branch          string        `json:"branch"`
-	IsClean         bool          `json:"is_clean"`
-	ChangedFiles    int           `json:"changed_files"`
-	LastCommitTime  time.Time     `json:"last_commit_time"`
-	UnpushedCommits int           `json:"unpushed_commits"`
-	UnpulledCommits int           `json:"unpulled_commits"`
-	IsGhost         bool          `json:"is_ghost"`
-	RecentCommits   []Commit      `json:"recent_commits,omitempty"`
-	LinesChanged    *LinesChanged `json:"lines_changed,omitempty"`
-	DailyActivity   []int         `json:"daily_activity,omitempty"`
+	Name            string          `json:"name"`
+	Path            string          `json:"path"`
+	Root            string          `json:"root"`
+	Kind            RepoKind        `json:"kind"`
+	Worktree        WorktreeKind    `json:"worktree,omitempty"`
+	Parent          string          `json:"parent,omitempty"`
+	Branch          string          `json:"branch"`
+	IsClean         bool            `json:"is_clean"`
+	ChangedFiles    int             `json:"changed_files"`
+	Changes         *WorktreeStatus `json:"changes,omitempty"`
+	LastCommitTime  time.Time       `json:"last_commit_time"`
+	UnpushedCommits int             `json:"unpushed_commits"`
+	UnpulledCommits int             `json:"unpulled_commits"`
+	Upstream        *TrackingRef    `json:"upstream,omitempty"`
+	Push            *TrackingRef    `json:"push,omitempty"`
+	DefaultBranch   string          `json:"default_branch,omitempty"`
+	Branches        []BranchStatus  `json:"branches,omitempty"`
+	IsGhost         bool            `json:"is_ghost"`
+	AtRisk          bool            `json:"at_risk"`
+	Stashes         []Stash         `json:"stashes,omitempty"`
+	Operations      []Operation     `json:"operations,omitempty"`
+	Detached        *DetachedHead   `json:"detached,omitempty"`
+	Fetch           *FetchResult    `json:"fetch,omitempty"`
+	Authors         []string        `json:"authors,omitempty"`
+	Heatmap         *Heatmap        `json:"heatmap,omitempty"`
+	Release         *ReleaseInfo    `json:"release,omitempty"`
+	DefaultRelease  *ReleaseInfo    `json:"default_release,omitempty"`
+	NoReleases      bool            `json:"no_releases,omitempty"`
+	RecentCommits   []Commit        `json:"recent_commits,omitempty"`
+	LinesChanged    *LinesChanged   `json:"lines_changed,omitempty"`
+	DailyActivity   []int           `json:"daily_activity,omitempty"`
+	Bare            *BareInfo       `json:"bare,omitempty"`
+	Remotes         []string        `json:"remotes,omitempty"`
+	RootCommit      string          `json:"root_commit,omitempty"`
+	// Extra holds the results of probes outside the built-in set, keyed by
+	// probe name; ProbeErrors holds the error of any probe that failed.
+	Extra       map[string]any    `json:"extra,omitempty"`
+	ProbeErrors map[string]string `json:"probe_errors,omitempty"`
+
+	// commonDir identifies the repository behind a worktree, and
+	// activityCommits maps each commit counted in DailyActivity to its index
+	// there, and heatmapCommits each commit in Heatmap to its day, so the
+	// tree-wide tally and heatmap count shared commits once.
+	commonDir       string
+	activityCommits map[string]int
+	heatmapCommits  map[string]time.Time
+
+	// authorsErr says why Authors could not include the user's own identity.
+	authorsErr error
+}
+
+// WorktreeStatus breaks ChangedFiles down by kind. Files is only filled in
+// detail mode; each Code is the two-letter XY status from porcelain v2, or
+// "??" and "!!" for untracked and ignored files.
+type WorktreeStatus struct {
+	Staged    int            `json:"staged"`
+	Modified  int            `json:"modified"`
+	Deleted   int            `json:"deleted"`
+	Renamed   int            `json:"renamed"`
+	Untracked int            `json:"untracked"`
+	Ignored   int            `json:"ignored,omitempty"`
+	Unmerged  int            `json:"unmerged"`
+	Files     []WorktreeFile `json:"files,omitempty"`
+}
+
+type WorktreeFile struct {
+	Code     string `json:"code"`
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+}
+
+// TrackingRef is the remote-tracking ref a branch was compared against. Gone
+// means the branch is configured to track a ref that no longer exists locally.
+type TrackingRef struct {
+	Ref    string `json:"ref"`
+	Ahead  int    `json:"ahead"`
+	Behind int    `json:"behind"`
+	Gone   bool   `json:"gone,omitempty"`
+}
+
+// BranchStatus describes one local branch. Default is the comparison against
+// the default branch and is omitted for the default branch itself.
+type BranchStatus struct {
+	Name           string       `json:"name"`
+	Current        bool         `json:"current,omitempty"`
+	LastCommitTime time.Time    `json:"last_commit_time"`
+	Upstream       *TrackingRef `json:"upstream,omitempty"`
+	Push           *TrackingRef `json:"push,omitempty"`
+	Default        *TrackingRef `json:"default,omitempty"`
+	Merged         bool         `json:"merged"`
+	Unpushed       int          `json:"unpushed_commits"`
+	NeverPushed    bool         `json:"never_pushed,omitempty"`
+}
+
+// ReleaseInfo is the highest semver tag reachable from From. Subjects holds
+// the newest unreleased commit subjects and is only filled in detail mode.
+type ReleaseInfo struct {
+	Tag              string    `json:"tag"`
+	Date             time.Time `json:"date"`
+	From             string    `json:"from"`
+	CommitsSince     int       `json:"commits_since"`
+	OldestUnreleased time.Time `json:"oldest_unreleased,omitzero"`
+	Subjects         []string  `json:"subjects,omitempty"`
+}
+
+// DetachedHead describes a HEAD that is not on a branch. Unreachable counts
+// commits that no branch, remote-tracking ref or tag reaches, which git will
+// eventually garbage collect.
+type DetachedHead struct {
+	Commit      string   `json:"commit"`
+	Tag         string   `json:"tag,omitempty"`
+	Distance    int      `json:"distance"`
+	ContainedIn []string `json:"contained_in,omitempty"`
+	Unreachable int      `json:"unreachable_commits"`
+}
+
+type FetchOutcome string
+
+const (
+	FetchOK        FetchOutcome = "ok"
+	FetchAuth      FetchOutcome = "auth"
+	FetchNetwork   FetchOutcome = "network"
+	FetchTimeout   FetchOutcome = "timeout"
+	FetchNoRemote  FetchOutcome = "no_remote"
+	FetchCancelled FetchOutcome = "cancelled"
+	FetchFailed    FetchOutcome = "failed"
+)
+
+// FetchResult records how `git fetch` went for a repo. Message is the first
+// line of git's error output when it failed.
+type FetchResult struct {
+	Outcome  FetchOutcome  `json:"outcome"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+type UnfinishedReason string
+
+const (
+	UnfinishedTimeout   UnfinishedReason = "timeout"
+	UnfinishedCancelled UnfinishedReason = "cancelled"
+)
+
+// UnfinishedRepo is a repo left out of the results because its analysis was
+// cut short. Stage is the step it was on, or empty if it never started.
+type UnfinishedRepo struct {
+	Name    string           `json:"name"`
+	Path    string           `json:"path"`
+	Reason  UnfinishedReason `json:"reason"`
+	Stage   string           `json:"stage,omitempty"`
+	Elapsed time.Duration    `json:"elapsed,omitempty"`
+}
+
+type OperationKind string
+
+const (
+	OpRebase     OperationKind = "rebase"
+	OpApply      OperationKind = "am"
+	OpMerge      OperationKind = "merge"
+	OpCherryPick OperationKind = "cherry-pick"
+	OpRevert     OperationKind = "revert"
+	OpBisect     OperationKind = "bisect"
+)
+
+// Operation is a merge, rebase, cherry-pick, revert or bisect left in
+// progress. Step and Total count rebase and am patches; for a bisect, Step is
+// the number of verdicts given so far and Total is unknown.
+type Operation struct {
+	Kind    OperationKind `json:"kind"`
+	Step    int           `json:"step,omitempty"`
+	Total   int           `json:"total,omitempty"`
+	Branch  string        `json:"branch,omitempty"`
+	Started time.Time     `json:"started,omitzero"`
+}
+
+// Stash is one entry of the refs/stash reflog. Stale stashes are older than
+// the ghost threshold.
+type Stash struct {
+	Ref     string    `json:"ref"`
+	Branch  string    `json:"branch,omitempty"`
+	Message string    `json:"message"`
+	Created time.Time `json:"created"`
+	Files   int       `json:"files"`
+	Stale   bool      `json:"stale,omitempty"`
+}
+
+type BareInfo struct {
+	Branches     int       `json:"branches"`
+	SizeBytes    int64     `json:"size_bytes"`
+	MirrorRemote string    `json:"mirror_remote,omitempty"`
+	LastFetch    time.Time `json:"last_fetch,omitzero"`
 }
 
 type Commit struct {
@@ -35,22 +304,143 @@ type Commit struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// Heatmap is a contribution grid: Days[i] counts commits on Start plus i days.
+// Start is always a Sunday, so every seventh entry begins a new week column.
+type Heatmap struct {
+	Start string `json:"start"`
+	Days  []int  `json:"days"`
+	Total int    `json:"total"`
+	Max   int    `json:"max"`
+}
+
+// Activity totals every repo's DailyActivity over the activity window.
+type Activity struct {
+	Days []DayActivity `json:"days"`
+	// Repos lists repos with commits in the window, busiest first.
+	Repos []RepoActivity `json:"repos,omitempty"`
+	// Weekdays counts commits by day of the week, Sunday first.
+	Weekdays [7]int `json:"weekdays"`
+	Total    int    `json:"total"`
+	Today    int    `json:"today"`
+}
+
+type DayActivity struct {
+	Date    string         `json:"date"`
+	Commits int            `json:"commits"`
+	Repos   []RepoActivity `json:"repos,omitempty"`
+}
+
+type RepoActivity struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Commits int    `json:"commits"`
+}
+
 type LinesChanged struct {
-	Added   int           `json:"added"`
-	Removed int           `json:"removed"`
-	Period  time.Duration `json:"period"`
+	Added      int             `json:"added"`
+	Removed    int             `json:"removed"`
+	Period     time.Duration   `json:"period"`
+	ByLanguage []LanguageLines `json:"by_language,omitempty"`
+	// Excluded lines belong to generated, vendored or excluded files and are
+	// not part of Added and Removed.
+	ExcludedAdded   int `json:"excluded_added,omitempty"`
+	ExcludedRemoved int `json:"excluded_removed,omitempty"`
+}
+
+type LanguageLines struct {
+	Language string `json:"language"`
+	Added    int    `json:"added"`
+	Removed  int    `json:"removed"`
 }
 
 type ScanResult struct {
 	Repos        []RepoStatus   `json:"repos"`
 	TotalRepos   int            `json:"total_repos"`
+	Roots        []RootSummary  `json:"roots"`
 	NonGitPaths  []string       `json:"non_git_paths,omitempty"`
+	NonGitDirs   []NonGitDir    `json:"non_git_dirs,omitempty"`
 	DailyCommits map[string]int `json:"daily_commits,omitempty"`
-	ScanDuration time.Duration  `json:"scan_duration"`
-	Errors       []ScanError    `json:"errors,omitempty"`
+	Activity     *Activity      `json:"activity,omitempty"`
+	Heatmap      *Heatmap       `json:"heatmap,omitempty"`
+	Timezone     string         `json:"timezone"`
+	DayStartHour int            `json:"day_start_hour,omitempty"`
+	Today        string         `json:"today"`
+	// Stopped is set when the scan budget ran out or the scan was cancelled
+	// before every repo was analyzed.
+	Stopped      UnfinishedReason `json:"stopped,omitempty"`
+	Unfinished   []UnfinishedRepo `json:"unfinished,omitempty"`
+	ScanDuration time.Duration    `json:"scan_duration"`
+	Errors       []ScanError      `json:"errors,omitempty"`
+	Ignored      []IgnoredPath    `json:"ignored,omitempty"`
+	Duplicates   []DuplicateGroup `json:"duplicates,omitempty"`
 }
 
+type DuplicateGroup struct {
+	Key    string           `json:"key"`
+	Clones []DuplicateClone `json:"clones"`
+}
+
+// DuplicateClone is one member of a DuplicateGroup. A clone with no remote
+// has nothing to compare against, so it is marked NoRemote rather than
+// reporting its whole history as local-only commits.
+type DuplicateClone struct {
+	Name             string    `json:"name"`
+	Path             string    `json:"path"`
+	Kind             RepoKind  `json:"kind"`
+	LastCommitTime   time.Time `json:"last_commit_time"`
+	Newest           bool      `json:"newest"`
+	LocalOnlyCommits int       `json:"local_only_commits"`
+	NoRemote         bool      `json:"no_remote,omitempty"`
+}
+
+type RootSummary struct {
+	Path            string   `json:"path"`
+	Registry        bool     `json:"registry,omitempty"`
+	MaxDepth        int      `json:"max_depth"`
+	TotalRepos      int      `json:"total_repos"`
+	DirtyRepos      int      `json:"dirty_repos"`
+	GhostRepos      int      `json:"ghost_repos"`
+	AtRiskRepos     int      `json:"at_risk_repos"`
+	UnpushedCommits int      `json:"unpushed_commits"`
+	UnpulledCommits int      `json:"unpulled_commits"`
+	NonGitPaths     []string `json:"non_git_paths,omitempty"`
+}
+
+type NonGitKind string
+
+const (
+	NonGitProject NonGitKind = "project"
+	NonGitSource  NonGitKind = "source"
+	NonGitOther   NonGitKind = "other"
+)
+
+type NonGitDir struct {
+	Path         string     `json:"path"`
+	Kind         NonGitKind `json:"kind"`
+	Manifests    []string   `json:"manifests,omitempty"`
+	SourceFiles  int        `json:"source_files"`
+	SizeBytes    int64      `json:"size_bytes"`
+	LastModified time.Time  `json:"last_modified,omitzero"`
+	Partial      bool       `json:"partial,omitempty"`
+}
+
+type ScanErrorKind string
+
+const (
+	ErrorMissing ScanErrorKind = "missing"
+	ErrorCache   ScanErrorKind = "cache"
+	ErrorRoot    ScanErrorKind = "root"
+	ErrorAuthors ScanErrorKind = "authors"
+)
+
 type ScanError struct {
-	Path    string `json:"path"`
-	Message string `json:"message"`
+	Path    string        `json:"path"`
+	Message string        `json:"message"`
+	Kind    ScanErrorKind `json:"kind,omitempty"`
+}
+
+type IgnoredPath struct {
+	Path   string `json:"path"`
+	Rule   string `json:"rule"`
+	Source string `json:"source"`
 }
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+type trackingConfig struct {
+	upstream   string
+	push       string
+	pushRemote string
+}
+
+// trackingRefs asks git for each branch's @{upstream} and @{push}, so
+// branch.<name>.merge, branch.<name>.pushRemote, remote.pushDefault and
+// push.default resolve exactly as they do for git itself, global config included.
+func trackingRefs(ctx context.Context, repoPath string, patterns ...string) map[string]trackingConfig {
+	args := append([]string{"-C", repoPath, "for-each-ref",
+		"--format=%(refname)%00%(upstream)%00%(push)%00%(push:remotename)"}, patterns...)
+	if len(patterns) == 0 {
+		args = append(args, "refs/heads")
+	}
+	out, err := gitCommand(ctx, args...).Output()
+	if err != nil {
+		return nil
+	}
+
+	refs := make(map[string]trackingConfig)
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		refs[fields[0]] = trackingConfig{
+			upstream:   fields[1],
+			push:       fields[2],
+			pushRemote: fields[3],
+		}
+	}
+	return refs
+}
+
+// pushRef falls back to <remote>/<branch> when git cannot name a push
+// destination, as with push.default=simple in a triangular workflow or a
+// branch pushed without -u. It is only used if that remote-tracking ref exists.
+func (t trackingConfig) pushRef(repo *git.Repository, branch plumbing.ReferenceName) string {
+	if t.push != "" {
+		return t.push
+	}
+	remote := t.pushRemote
+	if remote == "" {
+		remote = "origin"
+	}
+	ref := plumbing.NewRemoteReferenceName(remote, branch.Short())
+	if _, err := repo.Reference(ref, true); err != nil {
+		return ""
+	}
+	return ref.String()
+}
+
+func compareRef(ctx context.Context, repo *git.Repository, local plumbing.Hash, ref string) *TrackingRef {
+	if ref == "" {
+		return nil
+	}
+	tracking := &TrackingRef{Ref: ref}
+
+	remoteRef, err := repo.Reference(plumbing.ReferenceName(ref), true)
+	if err != nil {
+		tracking.Gone = true
+		return tracking
+	}
+	tracking.Ahead, tracking.Behind = aheadBehindCounts(ctx, repo, local, remoteRef.Hash())
+	return tracking
+}
+
+func aheadBehindCounts(ctx context.Context, repo *git.Repository, localHash, remoteHash plumbing.Hash) (int, int) {
+	if localHash == remoteHash {
+		return 0, 0
+	}
+
+	localCommit, err := repo.CommitObject(localHash)
+	if err != nil {
+		return 0, 0
+	}
+	remoteCommit, err := repo.CommitObject(remoteHash)
+	if err != nil {
+		return 0, 0
+	}
+
+	mergeBase, err := localCommit.MergeBase(remoteCommit)
+	if err != nil || len(mergeBase) == 0 {
+		return 0, 0
+	}
+
+	base := mergeBase[0].Hash
+	return countCommitsBetween(ctx, repo, base, localHash), countCommitsBetween(ctx, repo, base, remoteHash)
+}
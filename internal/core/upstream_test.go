@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runGit runs git in dir with a fixed identity, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=Pulse", "GIT_AUTHOR_EMAIL=pulse@example.com",
+		"GIT_COMMITTER_NAME=Pulse", "GIT_COMMITTER_EMAIL=pulse@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+}
+
+func TestAnalyzeRemoteStatusTriangular(t *testing.T) {
+	requireGit(t)
+	root := t.TempDir()
+	upstream := filepath.Join(root, "upstream")
+	fork := filepath.Join(root, "fork")
+	clone := filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "-q", "-b", "main", upstream)
+	runGit(t, upstream, "commit", "-q", "--allow-empty", "-m", "base")
+	runGit(t, root, "clone", "-q", "--bare", upstream, fork)
+	runGit(t, root, "clone", "-q", fork, clone)
+	runGit(t, clone, "remote", "add", "upstream", upstream)
+	runGit(t, clone, "fetch", "-q", "upstream")
+	runGit(t, clone, "checkout", "-q", "-b", "feature", "--track", "upstream/main")
+	runGit(t, clone, "config", "branch.feature.pushRemote", "origin")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "one")
+	runGit(t, clone, "push", "-q", "origin", "feature")
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "two")
+	runGit(t, upstream, "commit", "-q", "--allow-empty", "-m", "upstream")
+	runGit(t, clone, "fetch", "-q", "upstream")
+
+	repo, err := openRepo(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := &RepoStatus{}
+	NewAnalyzer(ScanConfig{}).analyzeRemoteStatus(context.Background(), repo, clone, status)
+
+	want := TrackingRef{Ref: "refs/remotes/upstream/main", Ahead: 2, Behind: 1}
+	if status.Upstream == nil || *status.Upstream != want {
+		t.Errorf("upstream = %+v, want %+v", status.Upstream, want)
+	}
+	want = TrackingRef{Ref: "refs/remotes/origin/feature", Ahead: 1}
+	if status.Push == nil || *status.Push != want {
+		t.Errorf("push = %+v, want %+v", status.Push, want)
+	}
+	if status.UnpushedCommits != 1 || status.UnpulledCommits != 1 {
+		t.Errorf("unpushed/unpulled = %d/%d, want 1/1", status.UnpushedCommits, status.UnpulledCommits)
+	}
+}
@@ -0,0 +1,87 @@
+package core
+
+import "strings"
+
+type porcelainBranch struct {
+	head    string
+	initial bool
+}
+
+// parsePorcelainV2 reads `git status --porcelain=v2 --branch -z`. A file can
+// count in more than one bucket, e.g. staged and then modified again, so
+// changed counts entries rather than summing the buckets.
+func parsePorcelainV2(out []byte, withFiles bool) (st WorktreeStatus, branch porcelainBranch, changed int) {
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 2 {
+			continue
+		}
+
+		var file WorktreeFile
+		switch entry[0] {
+		case '#':
+			if head, ok := strings.CutPrefix(entry, "# branch.head "); ok {
+				branch.head = head
+			} else if entry == "# branch.oid (initial)" {
+				branch.initial = true
+			}
+			continue
+		case '1':
+			fields := strings.SplitN(entry, " ", 9)
+			if len(fields) < 9 {
+				continue
+			}
+			file = WorktreeFile{Code: fields[1], Path: fields[8]}
+			st.count(file.Code)
+		case '2':
+			fields := strings.SplitN(entry, " ", 10)
+			if len(fields) < 10 {
+				continue
+			}
+			file = WorktreeFile{Code: fields[1], Path: fields[9]}
+			if i+1 < len(entries) {
+				i++
+				file.OrigPath = entries[i]
+			}
+			st.Renamed++
+			st.count(file.Code)
+		case 'u':
+			fields := strings.SplitN(entry, " ", 11)
+			if len(fields) < 11 {
+				continue
+			}
+			file = WorktreeFile{Code: fields[1], Path: fields[10]}
+			st.Unmerged++
+		case '?':
+			file = WorktreeFile{Code: "??", Path: entry[2:]}
+			st.Untracked++
+		case '!':
+			file = WorktreeFile{Code: "!!", Path: entry[2:]}
+			st.Ignored++
+		default:
+			continue
+		}
+
+		if file.Code != "!!" {
+			changed++
+		}
+		if withFiles {
+			st.Files = append(st.Files, file)
+		}
+	}
+	return st, branch, changed
+}
+
+func (st *WorktreeStatus) count(code string) {
+	index, worktree := code[0], code[1]
+	if index != '.' {
+		st.Staged++
+	}
+	switch {
+	case index == 'D' || worktree == 'D':
+		st.Deleted++
+	case worktree == 'M' || worktree == 'T':
+		st.Modified++
+	}
+}
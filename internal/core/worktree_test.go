@@ -0,0 +1,41 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePorcelainV2(t *testing.T) {
+	out := strings.Join([]string{
+		"# branch.oid (initial)",
+		"# branch.head main",
+		"1 M. N... 100644 100644 100644 aaa bbb staged.go",
+		"1 MM N... 100644 100644 100644 aaa bbb both.go",
+		"1 .D N... 100644 100644 000000 aaa aaa gone.go",
+		"2 R. N... 100644 100644 100644 aaa aaa R100 new name.go",
+		"old name.go",
+		"u UU N... 100644 100644 100644 100644 aaa bbb ccc conflict.go",
+		"? scratch.txt",
+		"! build/",
+		"",
+	}, "\x00")
+
+	st, branch, changed := parsePorcelainV2([]byte(out), true)
+
+	want := WorktreeStatus{Staged: 3, Modified: 1, Deleted: 1, Renamed: 1, Untracked: 1, Ignored: 1, Unmerged: 1}
+	files := st.Files
+	st.Files = nil
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("counts = %+v, want %+v", st, want)
+	}
+	if changed != 6 {
+		t.Errorf("changed = %d, want 6", changed)
+	}
+	if !branch.initial || branch.head != "main" {
+		t.Errorf("branch = %+v", branch)
+	}
+	if len(files) != 7 || files[3].Path != "new name.go" || files[3].OrigPath != "old name.go" {
+		t.Errorf("files = %+v", files)
+	}
+}
@@ -6,7 +6,36 @@ import (
 	"github.com/guidefari/pulse/internal/core"
 )
 
+type (
+	ScanConfig = core.ScanConfig
+	ScanResult = core.ScanResult
+	ScanRoot   = core.ScanRoot
+	RepoStatus = core.RepoStatus
+	Probe      = core.Probe
+	ProbeRepo  = core.ProbeRepo
+	ProbeInfo  = core.ProbeInfo
+	Cost       = core.Cost
+)
+
+const (
+	CostCheap     = core.CostCheap
+	CostModerate  = core.CostModerate
+	CostExpensive = core.CostExpensive
+)
+
 func Run(ctx context.Context, config core.ScanConfig) (*core.ScanResult, error) {
 	scanner := core.NewScanner(config)
 	return scanner.Scan(ctx)
 }
+
+// NewProbe wraps a function as a Probe for ScanConfig.Probes. Its result, if
+// not nil, appears in RepoStatus.Extra under name.
+func NewProbe(name string, cost Cost, run func(ctx context.Context, repo *ProbeRepo) (any, error), deps ...string) Probe {
+	return core.NewProbe(name, cost, run, deps...)
+}
+
+// Probes lists the built-in probes and those in config.Probes in the order a
+// scan with config would run them, marking which are enabled.
+func Probes(config ScanConfig) ([]ProbeInfo, error) {
+	return core.NewAnalyzer(config).Probes()
+}